@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
@@ -11,6 +13,7 @@ import (
 )
 
 type Config struct {
+	AppEnv     string
 	DBHost     string
 	DBPort     string
 	DBUser     string
@@ -18,6 +21,36 @@ type Config struct {
 	DBName     string
 	RedisHost  string
 	RedisPort  string
+	// EventBus selects how real-time events reach connected clients: "local" within a
+	// single instance, "redis" across instances through Redis pub/sub
+	EventBus string
+	// FreeSwipeQuota is the number of daily swipes for users without premium
+	FreeSwipeQuota int
+	// FreeSuperLikeQuota is the number of daily super likes for users without premium
+	FreeSuperLikeQuota int
+	// PassRecycleDays is after how many days a passed profile shows up in discovery again; 0 never
+	PassRecycleDays int
+	// SwipeUndoWindowSeconds is how long after a swipe it can still be undone
+	SwipeUndoWindowSeconds int
+	// RewindDailyLimit caps daily undos for rewind entitlements that set no limit
+	RewindDailyLimit int
+	// SubscriptionExpiryNoticeHours is how long before a subscription ends its user is warned
+	SubscriptionExpiryNoticeHours int
+	// ReportAutoHideThreshold is how many distinct users must report a user before their
+	// profile is hidden pending review; 0 disables hiding
+	ReportAutoHideThreshold int
+	// JWTKeys are every key accepted when verifying tokens
+	JWTKeys []JWTKeyConfig
+	// JWTActiveKeyID identifies the key new tokens are signed with; defaults to the first key
+	JWTActiveKeyID string
+}
+
+// JWTKeyConfig describes a token signing key. Value is the secret for HS256 and the
+// path to a PEM private key for RS256 and EdDSA.
+type JWTKeyConfig struct {
+	ID        string
+	Algorithm string
+	Value     string
 }
 
 // LoadConfig loads environment variables and returns the configuration struct
@@ -29,6 +62,7 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
+		AppEnv:     os.Getenv("APP_ENV"),
 		DBHost:     os.Getenv("DB_HOST"),
 		DBPort:     os.Getenv("DB_PORT"),
 		DBUser:     os.Getenv("DB_USER"),
@@ -36,7 +70,60 @@ func LoadConfig() *Config {
 		DBName:     os.Getenv("DB_NAME"),
 		RedisHost:  os.Getenv("REDIS_HOST"),
 		RedisPort:  os.Getenv("REDIS_PORT"),
+		EventBus:   os.Getenv("EVENT_BUS"),
+
+		FreeSwipeQuota:         getEnvInt("FREE_SWIPE_QUOTA", 10),
+		FreeSuperLikeQuota:     getEnvInt("FREE_SUPER_LIKE_QUOTA", 1),
+		PassRecycleDays:        getEnvInt("PASS_RECYCLE_DAYS", 0),
+		SwipeUndoWindowSeconds: getEnvInt("SWIPE_UNDO_WINDOW_SECONDS", 300),
+		RewindDailyLimit:       getEnvInt("REWIND_DAILY_LIMIT", 5),
+
+		SubscriptionExpiryNoticeHours: getEnvInt("SUBSCRIPTION_EXPIRY_NOTICE_HOURS", 72),
+		ReportAutoHideThreshold:       getEnvInt("REPORT_AUTO_HIDE_THRESHOLD", 3),
+		JWTKeys:                       parseJWTKeys(os.Getenv("JWT_KEYS")),
+		JWTActiveKeyID:                os.Getenv("JWT_ACTIVE_KEY_ID"),
+	}
+}
+
+// parseJWTKeys parses a comma separated list of <kid>:<algorithm>:<value> entries
+func parseJWTKeys(value string) []JWTKeyConfig {
+	var keys []JWTKeyConfig
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			log.Fatalf("Invalid JWT_KEYS entry %q, expected <kid>:<algorithm>:<value>", entry)
+		}
+		keys = append(keys, JWTKeyConfig{ID: parts[0], Algorithm: parts[1], Value: parts[2]})
+	}
+	return keys
+}
+
+// getEnvInt reads an integer environment variable, falling back to def when unset or invalid
+func getEnvInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value %q for %s, using default %d", value, key, def)
+		return def
 	}
+	return n
+}
+
+// UsesRedisEventBus reports whether real-time events are fanned out through Redis
+func (c *Config) UsesRedisEventBus() bool {
+	return c.EventBus == "redis"
+}
+
+// IsDevelopment reports whether the app runs in a local development environment
+func (c *Config) IsDevelopment() bool {
+	return c.AppEnv == "development"
 }
 
 // ConnectDB sets up and returns the GORM database connection
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS user_premia;
+DROP TABLE IF EXISTS premium_packages;
+DROP TABLE IF EXISTS matches;
+DROP TABLE IF EXISTS swipes;
+DROP TABLE IF EXISTS profiles;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,100 @@
+-- Databases created before versioned migrations already have these tables, built by
+-- GORM's AutoMigrate with the same names, so every statement tolerates them existing.
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    email TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    username TEXT NOT NULL,
+    profile_pic_url TEXT,
+    is_verified BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS profiles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users (id),
+    bio TEXT,
+    interests TEXT,
+    last_swipe_date TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_profiles_deleted_at ON profiles (deleted_at);
+
+CREATE TABLE IF NOT EXISTS swipes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users (id),
+    profile_id UUID NOT NULL REFERENCES profiles (id),
+    is_like BOOLEAN NOT NULL,
+    swipe_date TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS matches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_a_id UUID NOT NULL REFERENCES users (id),
+    user_b_id UUID NOT NULL REFERENCES users (id),
+    matched_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_matches_pair ON matches (user_a_id, user_b_id);
+CREATE INDEX IF NOT EXISTS idx_matches_user_b_id ON matches (user_b_id);
+
+CREATE TABLE IF NOT EXISTS premium_packages (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    package_name TEXT NOT NULL,
+    description TEXT,
+    price DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_premium_packages_deleted_at ON premium_packages (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_premia (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users (id),
+    package_id UUID NOT NULL REFERENCES premium_packages (id),
+    purchase_date TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_user_premia_deleted_at ON user_premia (deleted_at);
+
+-- AutoMigrate named the foreign keys fk_<table>_<relation>; later migrations refer to
+-- them by the names Postgres gives the constraints declared above
+DO $$
+DECLARE
+    legacy RECORD;
+BEGIN
+    FOR legacy IN
+        SELECT * FROM (VALUES
+            ('profiles', 'fk_profiles_user', 'profiles_user_id_fkey'),
+            ('swipes', 'fk_swipes_user', 'swipes_user_id_fkey'),
+            ('swipes', 'fk_swipes_profile', 'swipes_profile_id_fkey'),
+            ('matches', 'fk_matches_user_a', 'matches_user_a_id_fkey'),
+            ('matches', 'fk_matches_user_b', 'matches_user_b_id_fkey'),
+            ('user_premia', 'fk_user_premia_user', 'user_premia_user_id_fkey'),
+            ('user_premia', 'fk_user_premia_package', 'user_premia_package_id_fkey')
+        ) AS names (table_name, old_name, new_name)
+    LOOP
+        IF EXISTS (
+            SELECT 1 FROM pg_constraint
+            WHERE conname = legacy.old_name AND conrelid = legacy.table_name::regclass
+        ) THEN
+            EXECUTE format('ALTER TABLE %I RENAME CONSTRAINT %I TO %I',
+                legacy.table_name, legacy.old_name, legacy.new_name);
+        END IF;
+    END LOOP;
+END $$;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_user_premia_expires_at;
+DROP INDEX IF EXISTS idx_user_premia_user_status;
+
+ALTER TABLE user_premia
+    DROP COLUMN status,
+    DROP COLUMN expires_at,
+    DROP COLUMN start_date;
+
+ALTER TABLE premium_packages DROP COLUMN duration_months;
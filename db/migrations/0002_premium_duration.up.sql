@@ -0,0 +1,13 @@
+ALTER TABLE premium_packages ADD COLUMN duration_months INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE user_premia
+    ADD COLUMN start_date TIMESTAMPTZ,
+    ADD COLUMN expires_at TIMESTAMPTZ,
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+
+-- Subscriptions bought before packages had a duration keep granting premium forever
+UPDATE user_premia SET start_date = purchase_date;
+ALTER TABLE user_premia ALTER COLUMN start_date SET NOT NULL;
+
+CREATE INDEX idx_user_premia_user_status ON user_premia (user_id, status);
+CREATE INDEX idx_user_premia_expires_at ON user_premia (expires_at) WHERE status = 'active';
@@ -0,0 +1,15 @@
+CREATE TABLE package_entitlements (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    package_id UUID NOT NULL REFERENCES premium_packages (id) ON DELETE CASCADE,
+    feature TEXT NOT NULL,
+    "limit" INTEGER CHECK ("limit" > 0),
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX idx_package_entitlements_feature ON package_entitlements (package_id, feature);
+
+-- Existing packages keep the perks premium has always advertised
+INSERT INTO package_entitlements (package_id, feature, created_at, updated_at)
+SELECT id, feature, NOW(), NOW()
+FROM premium_packages
+CROSS JOIN (VALUES ('unlimited_swipes'), ('verified_badge')) AS perks (feature);
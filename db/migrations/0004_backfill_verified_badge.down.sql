@@ -0,0 +1,2 @@
+-- Data-only migration: the previous is_verified values cannot be restored
+SELECT 1;
@@ -0,0 +1,13 @@
+-- The verified badge now mirrors the verified_badge entitlement of the user's active packages
+UPDATE users
+SET is_verified = EXISTS (
+    SELECT 1
+    FROM user_premia
+    JOIN package_entitlements ON package_entitlements.package_id = user_premia.package_id
+    WHERE user_premia.user_id = users.id
+      AND user_premia.deleted_at IS NULL
+      AND user_premia.status = 'active'
+      AND user_premia.start_date <= NOW()
+      AND (user_premia.expires_at IS NULL OR user_premia.expires_at > NOW())
+      AND package_entitlements.feature = 'verified_badge'
+);
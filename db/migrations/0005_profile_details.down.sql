@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_profiles_user_id;
+
+ALTER TABLE profiles ALTER COLUMN interests DROP NOT NULL;
+ALTER TABLE profiles ALTER COLUMN interests DROP DEFAULT;
+ALTER TABLE profiles
+    ALTER COLUMN interests TYPE TEXT
+    USING replace(translate(interests::text, '[]"', ''), ', ', ',');
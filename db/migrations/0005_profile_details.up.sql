@@ -0,0 +1,14 @@
+-- Interests used to be a free-form comma separated string
+ALTER TABLE profiles
+    ALTER COLUMN interests TYPE JSONB
+    USING COALESCE(to_jsonb(array_remove(regexp_split_to_array(trim(interests), '\s*,\s*'), '')), '[]'::jsonb);
+ALTER TABLE profiles ALTER COLUMN interests SET DEFAULT '[]'::jsonb;
+ALTER TABLE profiles ALTER COLUMN interests SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_profiles_user_id ON profiles (user_id);
+
+-- Users who signed up before profiles were persisted get an empty one
+INSERT INTO profiles (user_id, bio, interests, created_at, updated_at)
+SELECT id, '', '[]'::jsonb, NOW(), NOW()
+FROM users
+WHERE NOT EXISTS (SELECT 1 FROM profiles WHERE profiles.user_id = users.id);
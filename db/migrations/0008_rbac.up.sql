@@ -0,0 +1,63 @@
+CREATE TABLE roles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX idx_roles_name ON roles (name);
+
+CREATE TABLE permissions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX idx_permissions_name ON permissions (name);
+
+CREATE TABLE role_permissions (
+    role_id UUID NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission_id UUID NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE user_roles (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    granted_by_id UUID REFERENCES users (id),
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (user_id, role_id)
+);
+CREATE INDEX idx_user_roles_role_id ON user_roles (role_id);
+
+INSERT INTO roles (name, description, created_at, updated_at) VALUES
+    ('super_admin', 'Full access, including managing other users'' roles', NOW(), NOW()),
+    ('admin', 'Manages premium packages and user accounts', NOW(), NOW()),
+    ('moderator', 'Handles user reports', NOW(), NOW());
+
+INSERT INTO permissions (name, description, created_at, updated_at) VALUES
+    ('admin.roles.manage', 'Grant and revoke roles', NOW(), NOW()),
+    ('admin.users.view', 'View users'' roles', NOW(), NOW()),
+    ('auth.sessions.revoke', 'Revoke other users'' sessions', NOW(), NOW()),
+    ('premium.packages.manage', 'Create, update and delete premium packages', NOW(), NOW()),
+    ('premium.status.view', 'View any user''s premium status', NOW(), NOW()),
+    ('moderation.reports.manage', 'Review and resolve user reports', NOW(), NOW());
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id
+FROM roles
+JOIN permissions ON
+    roles.name = 'super_admin'
+    OR (roles.name = 'admin' AND permissions.name IN (
+        'admin.users.view', 'auth.sessions.revoke', 'premium.packages.manage', 'premium.status.view'))
+    OR (roles.name = 'moderator' AND permissions.name IN ('moderation.reports.manage'));
+
+-- Carry over admins from the single role column
+INSERT INTO user_roles (user_id, role_id, created_at)
+SELECT users.id, roles.id, NOW()
+FROM users
+JOIN roles ON roles.name = 'admin'
+WHERE users.role = 'admin';
+
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,5 @@
+-- Supports the anti-join that hides already swiped profiles from discovery
+CREATE INDEX idx_swipes_user_target ON swipes (user_id, profile_id);
+
+-- Supports keyset pagination of candidates
+CREATE INDEX idx_users_created_at_id ON users (created_at, id);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_swipes_user_swipe_date;
+
+ALTER TABLE swipes DROP CONSTRAINT swipes_target_user_id_fkey;
+ALTER TABLE swipes RENAME COLUMN target_user_id TO profile_id;
+
+UPDATE swipes SET profile_id = profiles.id
+FROM profiles
+WHERE profiles.user_id = swipes.profile_id;
+
+DELETE FROM swipes WHERE NOT EXISTS (SELECT 1 FROM profiles WHERE profiles.id = swipes.profile_id);
+
+ALTER TABLE swipes
+    ADD CONSTRAINT swipes_profile_id_fkey FOREIGN KEY (profile_id) REFERENCES profiles (id);
@@ -0,0 +1,15 @@
+-- Swipes target users, the IDs discovery hands out, instead of profile rows
+ALTER TABLE swipes DROP CONSTRAINT swipes_profile_id_fkey;
+
+UPDATE swipes SET profile_id = profiles.user_id
+FROM profiles
+WHERE profiles.id = swipes.profile_id;
+
+ALTER TABLE swipes RENAME COLUMN profile_id TO target_user_id;
+
+DELETE FROM swipes WHERE NOT EXISTS (SELECT 1 FROM users WHERE users.id = swipes.target_user_id);
+
+ALTER TABLE swipes
+    ADD CONSTRAINT swipes_target_user_id_fkey FOREIGN KEY (target_user_id) REFERENCES users (id);
+
+CREATE INDEX idx_swipes_user_swipe_date ON swipes (user_id, swipe_date);
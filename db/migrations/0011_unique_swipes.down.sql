@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_swipes_user_target;
+CREATE INDEX idx_swipes_user_target ON swipes (user_id, target_user_id);
@@ -0,0 +1,15 @@
+-- A user decides about another user once; later changes update that swipe.
+-- Keep only the most recent of any duplicate swipes before enforcing it.
+DELETE FROM swipes WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (
+            PARTITION BY user_id, target_user_id
+            ORDER BY swipe_date DESC, created_at DESC NULLS LAST, id DESC
+        ) AS position
+        FROM swipes
+    ) ranked
+    WHERE position > 1
+);
+
+DROP INDEX IF EXISTS idx_swipes_user_target;
+CREATE UNIQUE INDEX idx_swipes_user_target ON swipes (user_id, target_user_id);
@@ -0,0 +1,12 @@
+CREATE TABLE idempotency_keys (
+    user_id UUID NOT NULL REFERENCES users (id),
+    key TEXT NOT NULL,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER NOT NULL,
+    response_body BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys (created_at);
@@ -0,0 +1,14 @@
+-- Per-user daily swipe counter, consumed in the same transaction as the swipe
+CREATE TABLE swipe_quotas (
+    user_id UUID NOT NULL REFERENCES users (id),
+    day DATE NOT NULL,
+    used INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day)
+);
+
+-- Carry over the swipes already made in the current quota window
+INSERT INTO swipe_quotas (user_id, day, used)
+SELECT user_id, swipe_date::date, COUNT(*)
+FROM swipes
+WHERE swipe_date >= CURRENT_DATE - 1
+GROUP BY user_id, swipe_date::date;
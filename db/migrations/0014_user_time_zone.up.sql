@@ -0,0 +1,2 @@
+-- IANA time zone the user's daily swipe quota resets in
+ALTER TABLE users ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC';
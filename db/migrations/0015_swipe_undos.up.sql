@@ -0,0 +1,11 @@
+-- Log of undone swipes, counted against the user's daily rewind allowance
+CREATE TABLE swipe_undos (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users (id),
+    target_user_id UUID NOT NULL REFERENCES users (id),
+    is_like BOOLEAN NOT NULL,
+    swiped_at TIMESTAMPTZ NOT NULL,
+    day DATE NOT NULL,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX idx_swipe_undos_user_day ON swipe_undos (user_id, day);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_swipes_super_likes;
+
+ALTER TABLE swipe_quotas DROP COLUMN super_used;
+
+ALTER TABLE swipe_undos ADD COLUMN is_like BOOLEAN;
+UPDATE swipe_undos SET is_like = swipe_type <> 'pass';
+ALTER TABLE swipe_undos ALTER COLUMN is_like SET NOT NULL;
+ALTER TABLE swipe_undos DROP COLUMN swipe_type;
+
+ALTER TABLE swipes ADD COLUMN is_like BOOLEAN;
+UPDATE swipes SET is_like = swipe_type <> 'pass';
+ALTER TABLE swipes ALTER COLUMN is_like SET NOT NULL;
+ALTER TABLE swipes DROP COLUMN swipe_type;
@@ -0,0 +1,18 @@
+-- Swipes carry a type instead of a like flag so they can express a super like
+ALTER TABLE swipes ADD COLUMN swipe_type TEXT;
+UPDATE swipes SET swipe_type = CASE WHEN is_like THEN 'like' ELSE 'pass' END;
+ALTER TABLE swipes ALTER COLUMN swipe_type SET NOT NULL;
+ALTER TABLE swipes
+    ADD CONSTRAINT swipes_swipe_type_check CHECK (swipe_type IN ('pass', 'like', 'super_like'));
+ALTER TABLE swipes DROP COLUMN is_like;
+
+ALTER TABLE swipe_undos ADD COLUMN swipe_type TEXT;
+UPDATE swipe_undos SET swipe_type = CASE WHEN is_like THEN 'like' ELSE 'pass' END;
+ALTER TABLE swipe_undos ALTER COLUMN swipe_type SET NOT NULL;
+ALTER TABLE swipe_undos DROP COLUMN is_like;
+
+-- Super likes have their own daily allowance
+ALTER TABLE swipe_quotas ADD COLUMN super_used INTEGER NOT NULL DEFAULT 0;
+
+-- Supports surfacing super likers first in the recipient's discovery feed
+CREATE INDEX idx_swipes_super_likes ON swipes (target_user_id, user_id) WHERE swipe_type = 'super_like';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_swipes_target_created_at_id;
@@ -0,0 +1,2 @@
+-- Supports listing the likes a user received, newest first
+CREATE INDEX idx_swipes_target_created_at_id ON swipes (target_user_id, created_at, id);
@@ -0,0 +1,5 @@
+DELETE FROM matches WHERE status <> 'active';
+
+ALTER TABLE matches DROP COLUMN ended_at;
+ALTER TABLE matches DROP COLUMN ended_by_id;
+ALTER TABLE matches DROP COLUMN status;
@@ -0,0 +1,6 @@
+-- Matches end when either user unmatches or blocks the other; who ended them and when is kept
+ALTER TABLE matches ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE matches
+    ADD CONSTRAINT matches_status_check CHECK (status IN ('active', 'unmatched', 'blocked'));
+ALTER TABLE matches ADD COLUMN ended_by_id UUID REFERENCES users (id);
+ALTER TABLE matches ADD COLUMN ended_at TIMESTAMPTZ;
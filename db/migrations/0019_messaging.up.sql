@@ -0,0 +1,32 @@
+-- Every match has one conversation; it is only reachable while the match is active
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    match_id UUID NOT NULL REFERENCES matches (id) ON DELETE CASCADE,
+    last_message_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX idx_conversations_match_id ON conversations (match_id);
+
+INSERT INTO conversations (match_id, created_at, updated_at)
+SELECT id, matched_at, matched_at FROM matches;
+
+CREATE TABLE messages (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    conversation_id UUID NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users (id),
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+CREATE INDEX idx_messages_conversation_created_at_id ON messages (conversation_id, created_at, id);
+CREATE INDEX idx_messages_deleted_at ON messages (deleted_at);
+
+-- How far each participant has read, for unread counts
+CREATE TABLE conversation_reads (
+    conversation_id UUID NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id),
+    last_read_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (conversation_id, user_id)
+);
@@ -0,0 +1,10 @@
+-- Short per-user log of delivered events, replayed to clients resuming an event stream
+CREATE TABLE user_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_user_events_user_id_id ON user_events (user_id, id);
+CREATE INDEX idx_user_events_created_at ON user_events (created_at);
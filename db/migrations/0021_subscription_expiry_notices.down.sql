@@ -0,0 +1 @@
+ALTER TABLE user_premia DROP COLUMN IF EXISTS expiry_notified_at;
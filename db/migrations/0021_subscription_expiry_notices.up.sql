@@ -0,0 +1,2 @@
+-- When the user was warned that the subscription is about to expire
+ALTER TABLE user_premia ADD COLUMN expiry_notified_at TIMESTAMPTZ;
@@ -0,0 +1,10 @@
+DELETE FROM role_permissions
+USING roles, permissions
+WHERE role_permissions.role_id = roles.id AND role_permissions.permission_id = permissions.id
+  AND roles.name = 'admin' AND permissions.name = 'moderation.reports.manage';
+
+DROP TABLE IF EXISTS moderation_decisions;
+DROP FUNCTION IF EXISTS forbid_moderation_decision_changes();
+DROP TABLE IF EXISTS reports;
+ALTER TABLE users DROP COLUMN IF EXISTS hidden_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS hidden_at;
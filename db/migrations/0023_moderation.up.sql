@@ -0,0 +1,58 @@
+-- Profiles hidden from other users by moderation, automatically after enough reports
+-- or by a moderator's decision
+ALTER TABLE users ADD COLUMN hidden_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN hidden_reason TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    reporter_id UUID NOT NULL REFERENCES users (id),
+    reported_id UUID NOT NULL REFERENCES users (id),
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending',
+    claimed_by_id UUID REFERENCES users (id),
+    claimed_at TIMESTAMPTZ,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ,
+    CHECK (reporter_id <> reported_id)
+);
+CREATE INDEX idx_reports_status_created_at_id ON reports (status, created_at, id);
+-- A user has at most one open report about another user
+CREATE UNIQUE INDEX idx_reports_open_pair ON reports (reporter_id, reported_id)
+    WHERE status IN ('pending', 'in_review');
+CREATE INDEX idx_reports_reported_id ON reports (reported_id);
+
+-- Append-only record of moderator decisions
+CREATE TABLE moderation_decisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    report_id UUID NOT NULL REFERENCES reports (id),
+    moderator_id UUID NOT NULL REFERENCES users (id),
+    reported_id UUID NOT NULL REFERENCES users (id),
+    action TEXT NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_moderation_decisions_reported_id ON moderation_decisions (reported_id, created_at);
+CREATE UNIQUE INDEX idx_moderation_decisions_report_id ON moderation_decisions (report_id);
+
+CREATE FUNCTION forbid_moderation_decision_changes() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'moderation decisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER moderation_decisions_immutable
+    BEFORE UPDATE OR DELETE ON moderation_decisions
+    FOR EACH ROW EXECUTE FUNCTION forbid_moderation_decision_changes();
+CREATE TRIGGER moderation_decisions_no_truncate
+    BEFORE TRUNCATE ON moderation_decisions
+    FOR EACH STATEMENT EXECUTE FUNCTION forbid_moderation_decision_changes();
+
+-- Admins work the moderation queue alongside moderators
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id
+FROM roles
+JOIN permissions ON permissions.name = 'moderation.reports.manage'
+WHERE roles.name = 'admin'
+ON CONFLICT DO NOTHING;
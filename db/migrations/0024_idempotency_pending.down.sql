@@ -0,0 +1,2 @@
+DELETE FROM idempotency_keys WHERE completed_at IS NULL;
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS completed_at;
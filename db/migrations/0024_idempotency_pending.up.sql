@@ -0,0 +1,4 @@
+-- A key is reserved before its request runs; completed_at stays NULL until the
+-- response is stored
+ALTER TABLE idempotency_keys ADD COLUMN completed_at TIMESTAMPTZ;
+UPDATE idempotency_keys SET completed_at = created_at;
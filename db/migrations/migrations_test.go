@@ -0,0 +1,214 @@
+package migrations
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The schema the server created with AutoMigrate before versioned migrations existed
+type legacyUser struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Email         string    `gorm:"uniqueIndex;not null"`
+	PasswordHash  string    `gorm:"not null"`
+	Username      string    `gorm:"uniqueIndex;not null"`
+	ProfilePicURL string
+	IsVerified    bool `gorm:"default:false"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+func (legacyUser) TableName() string { return "users" }
+
+type legacyProfile struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null"`
+	Bio           string
+	Interests     string
+	LastSwipeDate time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	User          legacyUser     `gorm:"foreignKey:UserID"`
+}
+
+func (legacyProfile) TableName() string { return "profiles" }
+
+type legacySwipe struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null"`
+	ProfileID uuid.UUID `gorm:"type:uuid;not null"`
+	IsLike    bool      `gorm:"not null"`
+	SwipeDate time.Time `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	User      legacyUser    `gorm:"foreignKey:UserID"`
+	Profile   legacyProfile `gorm:"foreignKey:ProfileID"`
+}
+
+func (legacySwipe) TableName() string { return "swipes" }
+
+type legacyPremiumPackage struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	PackageName string    `gorm:"not null"`
+	Description string
+	Price       decimal.Decimal `gorm:"type:decimal(10,2);not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (legacyPremiumPackage) TableName() string { return "premium_packages" }
+
+type legacyUserPremium struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null"`
+	PackageID    uuid.UUID `gorm:"type:uuid;not null"`
+	PurchaseDate time.Time `gorm:"not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt       `gorm:"index"`
+	User         legacyUser           `gorm:"foreignKey:UserID"`
+	Package      legacyPremiumPackage `gorm:"foreignKey:PackageID"`
+}
+
+func (legacyUserPremium) TableName() string { return "user_premia" }
+
+type legacyMatch struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserAID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_matches_pair"`
+	UserBID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_matches_pair;index"`
+	MatchedAt time.Time `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserA     legacyUser `gorm:"foreignKey:UserAID"`
+	UserB     legacyUser `gorm:"foreignKey:UserBID"`
+}
+
+func (legacyMatch) TableName() string { return "matches" }
+
+func TestUpAdoptsAutoMigratedDatabase(t *testing.T) {
+	tests := []struct {
+		name   string
+		models []interface{}
+	}{
+		// The first release had no matches table
+		{"without matches", []interface{}{&legacyUser{}, &legacyProfile{}, &legacySwipe{}, &legacyPremiumPackage{}, &legacyUserPremium{}}},
+		{"with matches", []interface{}{&legacyUser{}, &legacyProfile{}, &legacySwipe{}, &legacyMatch{}, &legacyPremiumPackage{}, &legacyUserPremium{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := db.AutoMigrate(tt.models...); err != nil {
+				t.Fatalf("failed to create the legacy schema: %v", err)
+			}
+
+			alice := legacyUser{ID: uuid.New(), Email: "alice@example.com", PasswordHash: "hash", Username: "alice"}
+			bob := legacyUser{ID: uuid.New(), Email: "bob@example.com", PasswordHash: "hash", Username: "bob"}
+			bobProfile := legacyProfile{ID: uuid.New(), UserID: bob.ID, Interests: "hiking, chess"}
+			swipe := legacySwipe{UserID: alice.ID, ProfileID: bobProfile.ID, IsLike: true, SwipeDate: time.Now()}
+			for _, row := range []interface{}{&alice, &bob, &bobProfile, &swipe} {
+				if err := db.Omit("User", "Profile").Create(row).Error; err != nil {
+					t.Fatalf("failed to seed the legacy schema: %v", err)
+				}
+			}
+
+			migrator, err := NewMigrator(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := migrator.Up(); err != nil {
+				t.Fatalf("Up on the legacy schema: %v", err)
+			}
+
+			var users int64
+			if err := db.Table("users").Count(&users).Error; err != nil {
+				t.Fatal(err)
+			}
+			if users != 2 {
+				t.Errorf("%d users after migrating, want 2", users)
+			}
+			var target uuid.UUID
+			if err := db.Raw("SELECT target_user_id FROM swipes WHERE user_id = ?", alice.ID).Scan(&target).Error; err != nil {
+				t.Fatal(err)
+			}
+			if target != bob.ID {
+				t.Errorf("migrated swipe targets %s, want %s", target, bob.ID)
+			}
+
+			statuses, err := migrator.Status()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, status := range statuses {
+				if !status.Applied {
+					t.Errorf("migration %d_%s is still pending", status.Version, status.Name)
+				}
+			}
+		})
+	}
+}
+
+// testDB returns a connection to an empty database on the server named by
+// TEST_DATABASE_URL, dropped when the test ends
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	serverURL := os.Getenv("TEST_DATABASE_URL")
+	if serverURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := openTestDB(serverURL)
+	if err != nil {
+		t.Fatalf("failed to connect to the test server: %v", err)
+	}
+	name := "datingapp_migrations_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if err := admin.Exec("CREATE DATABASE " + name).Error; err != nil {
+		closeTestDB(admin)
+		t.Fatalf("failed to create the test database: %v", err)
+	}
+
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Path = "/" + name
+	db, err := openTestDB(u.String())
+	t.Cleanup(func() {
+		if db != nil {
+			closeTestDB(db)
+		}
+		if err := admin.Exec("DROP DATABASE IF EXISTS " + name + " WITH (FORCE)").Error; err != nil {
+			t.Errorf("failed to drop the test database: %v", err)
+		}
+		closeTestDB(admin)
+	})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	return db
+}
+
+func openTestDB(dsn string) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+}
+
+func closeTestDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types pushed to clients
+const (
+	TypeMatchCreated         = "match.created"
+	TypeMatchEnded           = "match.ended"
+	TypeLikeReceived         = "like.received"
+	TypeMessageCreated       = "message.created"
+	TypeMessageRead          = "message.read"
+	TypeTyping               = "typing"
+	TypeSubscriptionExpiring = "subscription.expiring"
+)
+
+// TypeSessionRevoked tells every node to close the streams of a revoked session. It is
+// not pushed to clients.
+const TypeSessionRevoked = "session.revoked"
+
+// SessionRevoked is the data of a session.revoked event. A nil session ID stands for
+// every session of the user.
+type SessionRevoked struct {
+	SessionID *uuid.UUID `json:"session_id,omitempty"`
+}
+
+// Event is a domain event addressed to a single user
+type Event struct {
+	// ID orders the events logged for the user; it is 0 for ephemeral events
+	ID        int64           `json:"id,omitempty"`
+	Type      string          `json:"type"`
+	UserID    uuid.UUID       `json:"user_id"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// New builds an event for the user carrying data encoded as JSON
+func New(userID uuid.UUID, eventType string, data interface{}) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Type: eventType, UserID: userID, Data: raw, CreatedAt: time.Now()}, nil
+}
+
+// Ephemeral reports whether the event only matters at the moment it happens, so it
+// is not logged for clients that reconnect later
+func (e Event) Ephemeral() bool {
+	return e.Type == TypeTyping || e.Type == TypeSessionRevoked
+}
+
+// Publisher sends events to whoever is listening for their user
+type Publisher interface {
+	Publish(event Event) error
+}
+
+// Bus fans events out to every subscriber, on this node or across nodes depending
+// on the implementation
+type Bus interface {
+	Publisher
+	// Subscribe registers fn for every event published on the bus and returns a
+	// function that removes it
+	Subscribe(fn func(Event)) (unsubscribe func())
+	Close() error
+}
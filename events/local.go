@@ -0,0 +1,45 @@
+package events
+
+import "sync"
+
+// LocalBus delivers events to subscribers in the same process. It is enough when
+// the API runs on a single node.
+type LocalBus struct {
+	mu          sync.RWMutex
+	nextID      int
+	subscribers map[int]func(Event)
+}
+
+func NewLocalBus() *LocalBus {
+	return &LocalBus{subscribers: map[int]func(Event){}}
+}
+
+// Publish hands the event to every subscriber synchronously
+func (b *LocalBus) Publish(event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, fn := range b.subscribers {
+		fn(event)
+	}
+	return nil
+}
+
+func (b *LocalBus) Subscribe(fn func(Event)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = fn
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, id)
+	}
+}
+
+func (b *LocalBus) Close() error {
+	return nil
+}
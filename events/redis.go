@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBus relays events through a Redis pub/sub channel so that every node
+// delivers them to its own subscribers. Use it when the API runs on several nodes.
+type RedisBus struct {
+	client  *redis.Client
+	channel string
+	pubsub  *redis.PubSub
+	local   *LocalBus
+}
+
+// NewRedisBus subscribes to the channel and starts relaying its events to local subscribers
+func NewRedisBus(client *redis.Client, channel string) (*RedisBus, error) {
+	pubsub := client.Subscribe(context.Background(), channel)
+	// Wait for the subscription to be confirmed so no event published afterwards is missed
+	if _, err := pubsub.Receive(context.Background()); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	b := &RedisBus{client: client, channel: channel, pubsub: pubsub, local: NewLocalBus()}
+	go b.relay()
+	return b, nil
+}
+
+func (b *RedisBus) relay() {
+	for msg := range b.pubsub.Channel() {
+		var event Event
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			log.Printf("Dropping malformed event from Redis: %v", err)
+			continue
+		}
+		b.local.Publish(event)
+	}
+}
+
+// Publish sends the event to every node, this one included
+func (b *RedisBus) Publish(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(context.Background(), b.channel, payload).Err()
+}
+
+func (b *RedisBus) Subscribe(fn func(Event)) func() {
+	return b.local.Subscribe(fn)
+}
+
+func (b *RedisBus) Close() error {
+	return b.pubsub.Close()
+}
@@ -1,95 +1,333 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	// Embed the time zone database so user time zones resolve in minimal images
+	_ "time/tzdata"
 
 	"datingApp/config"
-	"datingApp/models"
+	"datingApp/db/migrations"
+	"datingApp/events"
+	"datingApp/middleware"
+	"datingApp/realtime"
 	"datingApp/repositories"
 	"datingApp/routes"
 	"datingApp/services"
+	"datingApp/tokens"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/driver/postgres"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
-func autoMigrate(db *gorm.DB) error {
-	log.Println("Resetting and migrating the database...")
-
-	// Drop all tables
-	err := db.Migrator().DropTable(
-		&models.User{},
-		&models.Profile{},
-		&models.Swipe{},
-		&models.PremiumPackage{},
-		&models.UserPremium{},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to drop tables: %w", err)
+const usage = `Usage:
+  datingApp                     apply pending migrations and start the server
+  datingApp migrate up          apply all pending migrations
+  datingApp migrate down [n]    roll back the last n migrations (default 1)
+  datingApp migrate status      list migrations and whether they are applied
+  datingApp reset-db            drop and recreate the whole schema (development only)
+  datingApp create-super-admin -email <email> -username <username>
+                                create the first super admin; the password is read
+                                from ADMIN_PASSWORD or standard input`
+
+// expireSubscriptions periodically flags premium subscriptions whose period has ended
+func expireSubscriptions(premiumService *services.PremiumService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		count, err := premiumService.ExpireSubscriptions()
+		if err != nil {
+			log.Printf("Failed to expire premium subscriptions: %v", err)
+			continue
+		}
+		if count > 0 {
+			log.Printf("Expired %d premium subscription(s)", count)
+		}
 	}
-	log.Println("Dropped all tables successfully")
+}
 
-	// Enable UUID extension
-	err = db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`).Error
-	if err != nil {
-		return fmt.Errorf("failed to create uuid extension: %w", err)
+// notifyExpiringSubscriptions periodically warns users whose premium is about to end
+func notifyExpiringSubscriptions(premiumService *services.PremiumService, within, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := premiumService.NotifyExpiringSubscriptions(within); err != nil {
+			log.Printf("Failed to notify expiring premium subscriptions: %v", err)
+		}
+	}
+}
+
+// purgeIdempotencyKeys periodically deletes stored responses that can no longer be replayed
+func purgeIdempotencyKeys(idempotencyRepo repositories.IdempotencyRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := idempotencyRepo.DeleteIdempotencyKeysBefore(time.Now().Add(-middleware.IdempotencyKeyTTL)); err != nil {
+			log.Printf("Failed to purge idempotency keys: %v", err)
+		}
+	}
+}
+
+// purgeUserEvents periodically deletes logged events too old to be replayed
+func purgeUserEvents(eventRepo repositories.EventRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := eventRepo.DeleteUserEventsBefore(time.Now().Add(-services.EventLogRetention)); err != nil {
+			log.Printf("Failed to purge user events: %v", err)
+		}
+	}
+}
+
+// loadKeySet builds the token signing keys from the configuration. Only development
+// may run without keys; it then signs with a throwaway key that dies with the process.
+func loadKeySet(cfg *config.Config) (*tokens.KeySet, error) {
+	if len(cfg.JWTKeys) == 0 {
+		if !cfg.IsDevelopment() {
+			return nil, fmt.Errorf("no JWT signing keys configured, set JWT_KEYS")
+		}
+		key, err := tokens.GenerateHMACKey("dev-ephemeral")
+		if err != nil {
+			return nil, err
+		}
+		log.Println("JWT_KEYS is not set, signing tokens with a throwaway key; they stop working on restart")
+		return tokens.NewKeySet(key.ID, key)
+	}
+
+	keys := make([]*tokens.Key, 0, len(cfg.JWTKeys))
+	for _, k := range cfg.JWTKeys {
+		key, err := tokens.LoadKey(k.ID, k.Algorithm, k.Value)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	activeKeyID := cfg.JWTActiveKeyID
+	if activeKeyID == "" {
+		activeKeyID = cfg.JWTKeys[0].ID
+	}
+	return tokens.NewKeySet(activeKeyID, keys...)
+}
+
+// newEventBus builds the bus real-time events travel on
+func newEventBus(cfg *config.Config) (events.Bus, error) {
+	if !cfg.UsesRedisEventBus() {
+		return events.NewLocalBus(), nil
+	}
+	client := redis.NewClient(&redis.Options{Addr: cfg.RedisHost + ":" + cfg.RedisPort})
+	return events.NewRedisBus(client, "events")
+}
+
+// createSuperAdmin bootstraps the first super admin account
+func createSuperAdmin(db *gorm.DB, args []string) error {
+	flags := flag.NewFlagSet("create-super-admin", flag.ContinueOnError)
+	email := flags.String("email", "", "email of the super admin")
+	username := flags.String("username", "", "username used if the account has to be created")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	if password == "" {
+		fmt.Print("Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		password = strings.TrimSpace(line)
 	}
 
-	// Re-run migrations
-	err = db.AutoMigrate(
-		&models.User{},
-		&models.Profile{},
-		&models.Swipe{},
-		&models.PremiumPackage{},
-		&models.UserPremium{},
-	)
+	rbacService := services.NewRBACService(repositories.NewRoleRepo(db), repositories.NewUserRepo(db),
+		repositories.NewSessionRepo(db), nil)
+	user, err := rbacService.BootstrapSuperAdmin(*email, *username, password)
 	if err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
+		return err
 	}
+	log.Printf("User %s (%s) is now a super admin", user.Email, user.ID)
+	return nil
+}
 
-	log.Println("Database migrations completed successfully")
+// runCommand executes a CLI subcommand instead of starting the server
+func runCommand(cfg *config.Config, db *gorm.DB, migrator *migrations.Migrator, args []string) error {
+	switch args[0] {
+	case "migrate":
+		if len(args) < 2 {
+			return fmt.Errorf("missing migrate action\n%s", usage)
+		}
+		switch args[1] {
+		case "up":
+			count, err := migrator.Up()
+			if err != nil {
+				return err
+			}
+			log.Printf("Applied %d migration(s)", count)
+		case "down":
+			steps := 1
+			if len(args) > 2 {
+				n, err := strconv.Atoi(args[2])
+				if err != nil || n < 1 {
+					return fmt.Errorf("invalid number of steps %q", args[2])
+				}
+				steps = n
+			}
+			count, err := migrator.Down(steps)
+			if err != nil {
+				return err
+			}
+			log.Printf("Rolled back %d migration(s)", count)
+		case "status":
+			statuses, err := migrator.Status()
+			if err != nil {
+				return err
+			}
+			for _, s := range statuses {
+				state := "pending"
+				if s.Applied {
+					state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, state)
+			}
+		default:
+			return fmt.Errorf("unknown migrate action %q\n%s", args[1], usage)
+		}
+	case "reset-db":
+		if !cfg.IsDevelopment() {
+			return fmt.Errorf("reset-db wipes all data and is only allowed with APP_ENV=development")
+		}
+		log.Println("Resetting the database...")
+		if err := migrator.Reset(); err != nil {
+			return err
+		}
+		log.Println("Database reset completed successfully")
+	case "create-super-admin":
+		if _, err := migrator.Up(); err != nil {
+			return err
+		}
+		return createSuperAdmin(db, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
 	return nil
 }
 
 func main() {
-	jwtSecret := "just_for_test"
 	// Load configuration from the .env file
 	cfg := config.LoadConfig()
 
-	// Database connection string using environment variables
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
-
 	// Connect to the database
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db := cfg.ConnectDB()
+
+	migrator, err := migrations.NewMigrator(db)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Fatalf("Failed to load migrations: %v", err)
 	}
 
-	// Run database migrations
-	if err := autoMigrate(db); err != nil {
+	if len(os.Args) > 1 {
+		if err := runCommand(cfg, db, migrator, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	keys, err := loadKeySet(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	// Apply pending database migrations
+	count, err := migrator.Up()
+	if err != nil {
 		log.Fatalf("Database migration failed: %v", err)
 	}
+	log.Printf("Applied %d pending migration(s)", count)
 
 	// Initialize repositories
 	userRepo := repositories.NewUserRepo(db)
 	swipeRepo := repositories.NewSwipeRepo(db)
 	premiumRepo := repositories.NewPremiumRepo(db)
+	matchRepo := repositories.NewMatchRepo(db)
+	profileRepo := repositories.NewProfileRepo(db)
+	sessionRepo := repositories.NewSessionRepo(db)
+	roleRepo := repositories.NewRoleRepo(db)
+	idempotencyRepo := repositories.NewIdempotencyRepo(db)
+	conversationRepo := repositories.NewConversationRepo(db)
+	eventRepo := repositories.NewEventRepo(db)
+	blockRepo := repositories.NewBlockRepo(db)
+	reportRepo := repositories.NewReportRepo(db)
+
+	bus, err := newEventBus(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to the event bus: %v", err)
+	}
+	defer bus.Close()
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo, jwtSecret)
-	swipeService := services.NewSwipeService(userRepo, swipeRepo)
-	premiumService := services.NewPremiumService(premiumRepo)
+	eventService := services.NewEventService(eventRepo, bus)
+	authService := services.NewAuthService(userRepo, sessionRepo, roleRepo, keys, eventService)
+	premiumService := services.NewPremiumService(premiumRepo, userRepo, eventService)
+	swipeService := services.NewSwipeService(userRepo, swipeRepo, blockRepo, premiumService, services.SwipeConfig{
+		FreeDailyQuota:      cfg.FreeSwipeQuota,
+		FreeDailySuperLikes: cfg.FreeSuperLikeQuota,
+		PassRecycleAfter:    time.Duration(cfg.PassRecycleDays) * 24 * time.Hour,
+		UndoWindow:          time.Duration(cfg.SwipeUndoWindowSeconds) * time.Second,
+		RewindDailyLimit:    cfg.RewindDailyLimit,
+	}, eventService)
+	matchService := services.NewMatchService(matchRepo, eventService)
+	likeService := services.NewLikeService(swipeRepo, profileRepo, premiumService)
+	messageService := services.NewMessageService(conversationRepo, eventService)
+	blockService := services.NewBlockService(blockRepo, userRepo)
+	moderationService := services.NewModerationService(reportRepo, userRepo, services.ModerationConfig{
+		AutoHideThreshold: cfg.ReportAutoHideThreshold,
+	})
+	profileService := services.NewProfileService(profileRepo, userRepo, blockRepo)
+	rbacService := services.NewRBACService(roleRepo, userRepo, sessionRepo, eventService)
+
+	hub := realtime.NewHub(bus, messageService)
+	defer hub.Close()
+
+	// Start background jobs
+	// Expiry also revokes perks such as the verified badge, so it runs often
+	go expireSubscriptions(premiumService, time.Minute)
+	go notifyExpiringSubscriptions(premiumService,
+		time.Duration(cfg.SubscriptionExpiryNoticeHours)*time.Hour, time.Hour)
+	go purgeIdempotencyKeys(idempotencyRepo, time.Hour)
+	go purgeUserEvents(eventRepo, time.Hour)
+
+	// Initialize router. The request log redacts access tokens passed in the query string.
+	router := gin.New()
+	router.Use(middleware.RequestLogger(), gin.Recovery())
 
-	// Initialize router
-	router := gin.Default()
+	// Authenticated routes share the same middleware, which also rejects revoked sessions
+	authMiddleware := middleware.JWTAuth(keys, authService)
+	streamAuth := middleware.StreamAuth(keys, authService)
+	idempotency := middleware.Idempotency(idempotencyRepo)
 
 	// Register routes
-	routes.RegisterAuthRoutes(router, authService)
-	routes.RegisterSwipeRoutes(router, swipeService, jwtSecret)
-	routes.RegisterPremiumRoutes(router, premiumService)
+	routes.RegisterAuthRoutes(router, authService, authMiddleware)
+	routes.RegisterJWKSRoutes(router, keys)
+	routes.RegisterSwipeRoutes(router, swipeService, authMiddleware, idempotency)
+	routes.RegisterMatchRoutes(router, matchService, authMiddleware)
+	routes.RegisterLikeRoutes(router, likeService, authMiddleware)
+	routes.RegisterConversationRoutes(router, messageService, authMiddleware, idempotency)
+	routes.RegisterBlockRoutes(router, blockService, authMiddleware)
+	routes.RegisterRealtimeRoutes(router, hub, streamAuth)
+	routes.RegisterEventRoutes(router, eventService, hub, streamAuth)
+	routes.RegisterProfileRoutes(router, profileService, authMiddleware)
+	routes.RegisterPremiumRoutes(router, premiumService, authMiddleware, rbacService)
+	routes.RegisterAdminRoutes(router, rbacService, authMiddleware)
+	routes.RegisterReportRoutes(router, moderationService, authMiddleware, rbacService)
 
 	if err := router.Run(":8080"); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
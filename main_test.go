@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"datingApp/config"
+)
+
+func TestLoadKeySet(t *testing.T) {
+	secret := strings.Repeat("s", 32)
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		wantErr bool
+	}{
+		{"production without keys", config.Config{AppEnv: "production"}, true},
+		{"unset environment without keys", config.Config{}, true},
+		{"development without keys", config.Config{AppEnv: "development"}, false},
+		{"production with a short secret", config.Config{
+			AppEnv:  "production",
+			JWTKeys: []config.JWTKeyConfig{{ID: "k1", Algorithm: "HS256", Value: "just_for_test"}},
+		}, true},
+		{"development with a short secret", config.Config{
+			AppEnv:  "development",
+			JWTKeys: []config.JWTKeyConfig{{ID: "k1", Algorithm: "HS256", Value: "just_for_test"}},
+		}, true},
+		{"production with a long secret", config.Config{
+			AppEnv:  "production",
+			JWTKeys: []config.JWTKeyConfig{{ID: "k1", Algorithm: "HS256", Value: secret}},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadKeySet(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loadKeySet error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
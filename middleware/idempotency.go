@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/models"
+)
+
+// IdempotencyKeyTTL is how long the response to an idempotent request is kept for replay
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyPendingTimeout is how long a key stays reserved for a request that never
+// finished, for instance because the server crashed while handling it
+const IdempotencyPendingTimeout = time.Minute
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyStore persists the responses of requests sent with an Idempotency-Key header
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(record *models.IdempotencyKey, expiredBefore, abandonedBefore time.Time) (bool, error)
+	GetIdempotencyKey(userID uuid.UUID, key string, since time.Time) (*models.IdempotencyKey, error)
+	CompleteIdempotencyKey(record *models.IdempotencyKey) error
+	ReleaseIdempotencyKey(record *models.IdempotencyKey) error
+}
+
+// Idempotency makes a request safe to retry when the client sends an Idempotency-Key
+// header. The key is reserved before the request runs, so a concurrent retry is
+// rejected with 409 until the first request finishes. The first response for a key
+// is then stored and replayed for every retry of the same request; reusing the key
+// for a different request is rejected. Server errors are not stored so they can be
+// retried. It must run after JWTAuth.
+func Idempotency(store IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		userIDStr, _ := c.Get("userID")
+		str, _ := userIDStr.(string)
+		userID, err := uuid.Parse(str)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+
+		now := time.Now()
+		record := &models.IdempotencyKey{
+			UserID:       userID,
+			Key:          key,
+			Method:       c.Request.Method,
+			Path:         c.Request.URL.Path,
+			RequestHash:  requestHash,
+			ResponseBody: []byte{},
+			CreatedAt:    now,
+		}
+		reserved, err := store.ReserveIdempotencyKey(record, now.Add(-IdempotencyKeyTTL), now.Add(-IdempotencyPendingTimeout))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+			c.Abort()
+			return
+		}
+		if !reserved {
+			replayIdempotentResponse(c, store, record)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := store.ReleaseIdempotencyKey(record); err != nil {
+				log.Printf("Failed to release Idempotency-Key %q: %v", key, err)
+			}
+			return
+		}
+		completedAt := time.Now()
+		record.StatusCode = recorder.Status()
+		record.ResponseBody = recorder.body.Bytes()
+		record.CompletedAt = &completedAt
+		if err := store.CompleteIdempotencyKey(record); err != nil {
+			log.Printf("Failed to store response for Idempotency-Key %q: %v", key, err)
+		}
+	}
+}
+
+// replayIdempotentResponse answers a request whose key is already held by the stored
+// record: the stored response is replayed once it is complete
+func replayIdempotentResponse(c *gin.Context, store IdempotencyStore, request *models.IdempotencyKey) {
+	defer c.Abort()
+
+	stored, err := store.GetIdempotencyKey(request.UserID, request.Key, request.CreatedAt.Add(-IdempotencyKeyTTL))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+		return
+	}
+	if stored != nil && (stored.Method != request.Method || stored.Path != request.Path || stored.RequestHash != request.RequestHash) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+		return
+	}
+	// The record may also have been released by a failed request in the meantime
+	if stored == nil || stored.CompletedAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is in progress"})
+		return
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(stored.StatusCode, "application/json; charset=utf-8", stored.ResponseBody)
+}
+
+// responseRecorder copies the response body while it is written to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
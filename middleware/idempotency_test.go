@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/models"
+)
+
+// memoryIdempotencyStore keeps idempotency records in memory with the same
+// reservation rules as the database
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyKey
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[string]models.IdempotencyKey{}}
+}
+
+func (s *memoryIdempotencyStore) ReserveIdempotencyKey(record *models.IdempotencyKey, expiredBefore, abandonedBefore time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := record.UserID.String() + "/" + record.Key
+	if stored, ok := s.records[id]; ok {
+		expired := stored.CreatedAt.Before(expiredBefore)
+		abandoned := stored.CompletedAt == nil && stored.CreatedAt.Before(abandonedBefore)
+		if !expired && !abandoned {
+			return false, nil
+		}
+	}
+	s.records[id] = *record
+	return true, nil
+}
+
+func (s *memoryIdempotencyStore) GetIdempotencyKey(userID uuid.UUID, key string, since time.Time) (*models.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.records[userID.String()+"/"+key]
+	if !ok || stored.CreatedAt.Before(since) {
+		return nil, nil
+	}
+	return &stored, nil
+}
+
+func (s *memoryIdempotencyStore) CompleteIdempotencyKey(record *models.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.UserID.String()+"/"+record.Key] = *record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) ReleaseIdempotencyKey(record *models.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, record.UserID.String()+"/"+record.Key)
+	return nil
+}
+
+// newIdempotentRouter serves POST /swipe for one authenticated user through the
+// idempotency middleware
+func newIdempotentRouter(store IdempotencyStore, userID uuid.UUID, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", userID.String())
+	})
+	router.POST("/swipe", Idempotency(store), handler)
+	return router
+}
+
+func sendIdempotent(router http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/swipe", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyRejectsConcurrentRetry(t *testing.T) {
+	var calls atomic.Int32
+	started, finish := make(chan struct{}), make(chan struct{})
+	router := newIdempotentRouter(newMemoryIdempotencyStore(), uuid.New(), func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-finish
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Swipe recorded"})
+	})
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() {
+		first <- sendIdempotent(router, "key-1", `{"target":"a"}`)
+	}()
+	<-started
+
+	// The first request is still running, so its retry must not run the handler again
+	retry := sendIdempotent(router, "key-1", `{"target":"a"}`)
+	if retry.Code != http.StatusConflict {
+		t.Errorf("concurrent retry status = %d, want %d", retry.Code, http.StatusConflict)
+	}
+	close(finish)
+	if w := <-first; w.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	replay := sendIdempotent(router, "key-1", `{"target":"a"}`)
+	if replay.Code != http.StatusOK || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry after completion = %d (replayed %q), want a replayed 200", replay.Code, replay.Header().Get("Idempotent-Replayed"))
+	}
+	if !strings.Contains(replay.Body.String(), "Swipe recorded") {
+		t.Errorf("replayed body = %s", replay.Body.String())
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("the handler ran %d times, want once", n)
+	}
+}
+
+func TestIdempotencyRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		firstCode  int
+		retryBody  string
+		wantCode   int
+		wantCalled int32
+	}{
+		{"same request is replayed", http.StatusCreated, `{"target":"a"}`, http.StatusCreated, 1},
+		{"client errors are replayed", http.StatusBadRequest, `{"target":"a"}`, http.StatusBadRequest, 1},
+		{"server errors can be retried", http.StatusInternalServerError, `{"target":"a"}`, http.StatusInternalServerError, 2},
+		{"different request is rejected", http.StatusCreated, `{"target":"b"}`, http.StatusUnprocessableEntity, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			router := newIdempotentRouter(newMemoryIdempotencyStore(), uuid.New(), func(c *gin.Context) {
+				calls.Add(1)
+				c.JSON(tt.firstCode, gin.H{})
+			})
+
+			if w := sendIdempotent(router, "key-1", `{"target":"a"}`); w.Code != tt.firstCode {
+				t.Fatalf("first request status = %d, want %d", w.Code, tt.firstCode)
+			}
+			if w := sendIdempotent(router, "key-1", tt.retryBody); w.Code != tt.wantCode {
+				t.Errorf("retry status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if n := calls.Load(); n != tt.wantCalled {
+				t.Errorf("the handler ran %d times, want %d", n, tt.wantCalled)
+			}
+		})
+	}
+}
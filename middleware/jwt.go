@@ -1,14 +1,62 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/tokens"
+)
+
+var (
+	ErrInvalidToken   = errors.New("invalid or expired token")
+	ErrInvalidClaims  = errors.New("invalid token claims")
+	ErrSessionRevoked = errors.New("session has been revoked")
 )
 
-func JWTAuth(secret string) gin.HandlerFunc {
+// SessionValidator reports whether the login session behind a token is still valid
+type SessionValidator interface {
+	IsSessionActive(sessionID uuid.UUID) (bool, error)
+}
+
+// Identity is the caller an access token was issued to
+type Identity struct {
+	UserID    interface{}
+	SessionID uuid.UUID
+	Roles     interface{}
+}
+
+// Authenticate validates an access token and the session behind it. It fails with
+// ErrInvalidToken, ErrInvalidClaims or ErrSessionRevoked when the token must be
+// rejected, and with any other error when the session could not be checked.
+func Authenticate(keys *tokens.KeySet, sessions SessionValidator, token string) (*Identity, error) {
+	// Parse and validate the token against the key named in its "kid" header
+	claims, err := keys.Parse(token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Reject tokens whose session was logged out or revoked
+	sid, _ := claims["sid"].(string)
+	sessionID, err := uuid.Parse(sid)
+	if err != nil {
+		return nil, ErrInvalidClaims
+	}
+	active, err := sessions.IsSessionActive(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrSessionRevoked
+	}
+
+	return &Identity{UserID: claims["user_id"], SessionID: sessionID, Roles: claims["roles"]}, nil
+}
+
+func JWTAuth(keys *tokens.KeySet, sessions SessionValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the token from the Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -26,31 +74,53 @@ func JWTAuth(secret string) gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-		// Parse and validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Ensure the signing method is HMAC
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.NewValidationError("invalid signing method", jwt.ValidationErrorSignatureInvalid)
-			}
-			return []byte(secret), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
-			return
-		}
+		authenticate(c, keys, sessions, parts[1])
+	}
+}
 
-		// Set the token	 claims to the context
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("userID", claims["user_id"]) // Assuming the token contains "user_id"
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
+// StreamAuth authenticates long-lived streaming connections. Browsers cannot set
+// headers on WebSocket and EventSource requests, so besides the Authorization header
+// the token may be passed in the access_token query parameter.
+func StreamAuth(keys *tokens.KeySet, sessions SessionValidator) gin.HandlerFunc {
+	bearer := JWTAuth(keys, sessions)
+	return func(c *gin.Context) {
+		token := c.Query("access_token")
+		if token == "" {
+			bearer(c)
 			return
 		}
+		authenticate(c, keys, sessions, token)
+	}
+}
 
-		c.Next()
+// authenticate validates the token and stores the caller in the context for the
+// handlers, or aborts with the matching error response
+func authenticate(c *gin.Context, keys *tokens.KeySet, sessions SessionValidator, token string) {
+	identity, err := Authenticate(keys, sessions, token)
+	if errors.Is(err, ErrInvalidToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return
+	}
+	if errors.Is(err, ErrInvalidClaims) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		c.Abort()
+		return
+	}
+	if errors.Is(err, ErrSessionRevoked) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+		c.Abort()
+		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate session"})
+		c.Abort()
+		return
+	}
+
+	c.Set("userID", identity.UserID) // Assuming the token contains "user_id"
+	c.Set("sessionID", identity.SessionID)
+	c.Set("roles", identity.Roles)
+
+	c.Next()
 }
@@ -0,0 +1,22 @@
+package middleware
+
+import "testing"
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/ws", "/ws"},
+		{"/events?last_event_id=5", "/events?last_event_id=5"},
+		{"/ws?access_token=secret", "/ws?access_token=REDACTED"},
+		{"/events?access_token=secret&last_event_id=5", "/events?access_token=REDACTED&last_event_id=5"},
+		{"/ws?access_token=a%zz", "/ws?REDACTED"},
+	}
+
+	for _, tt := range tests {
+		if got := redactQuery(tt.path); got != tt.want {
+			t.Errorf("redactQuery(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
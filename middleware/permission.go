@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PermissionChecker reports whether a user's roles grant a permission
+type PermissionChecker interface {
+	HasPermission(userID uuid.UUID, permission string) (bool, error)
+}
+
+// RequirePermission only lets through callers whose roles grant the permission.
+// Roles are looked up on every request so revoking one takes effect immediately.
+// It must run after JWTAuth.
+func RequirePermission(checker PermissionChecker, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr, _ := c.Get("userID")
+		str, _ := userIDStr.(string)
+		userID, err := uuid.Parse(str)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+			c.Abort()
+			return
+		}
+
+		allowed, err := checker.HasPermission(userID, permission)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -14,17 +14,85 @@ type User struct {
 	PasswordHash  string    `gorm:"not null"`
 	Username      string    `gorm:"uniqueIndex;not null"`
 	ProfilePicURL string
-	IsVerified    bool `gorm:"default:false"`
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	IsVerified    bool   `gorm:"default:false"`
+	TimeZone      string `gorm:"not null;default:UTC"` // IANA zone the user's day is counted in
+	// HiddenAt is set while moderation hides the profile from other users
+	HiddenAt     *time.Time
+	HiddenReason string `gorm:"not null;default:''"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+// Candidate is a user offered to another user in discovery
+type Candidate struct {
+	User
+	// SuperLikedYou is set when the candidate super liked the user browsing discovery
+	SuperLikedYou bool
+}
+
+// DefaultTimeZone is used for users who never set a time zone
+const DefaultTimeZone = "UTC"
+
+// Location returns the user's time zone, falling back to UTC when it is unknown
+func (u *User) Location() *time.Location {
+	loc, err := time.LoadLocation(u.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Built-in roles seeded by the migrations
+const (
+	RoleSuperAdmin = "super_admin"
+	RoleAdmin      = "admin"
+	RoleModerator  = "moderator"
+)
+
+// Permissions checked by privileged endpoints
+const (
+	PermissionManageRoles       = "admin.roles.manage"
+	PermissionViewUsers         = "admin.users.view"
+	PermissionRevokeSessions    = "auth.sessions.revoke"
+	PermissionManagePackages    = "premium.packages.manage"
+	PermissionViewPremiumStatus = "premium.status.view"
+	PermissionModerateReports   = "moderation.reports.manage"
+)
+
+type Role struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Name        string    `gorm:"not null;uniqueIndex"`
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Permissions []Permission `gorm:"many2many:role_permissions"`
+}
+
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Name        string    `gorm:"not null;uniqueIndex"`
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// UserRole grants a role to a user
+type UserRole struct {
+	UserID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	RoleID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	// GrantedByID is nil for roles granted from the command line
+	GrantedByID *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt   time.Time
+	User        User `gorm:"foreignKey:UserID"`
+	Role        Role `gorm:"foreignKey:RoleID"`
 }
 
 type Profile struct {
 	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	UserID        uuid.UUID `gorm:"type:uuid;not null"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
 	Bio           string
-	Interests     string
+	Interests     StringList `gorm:"type:jsonb;not null;default:'[]'"`
 	LastSwipeDate time.Time
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
@@ -32,16 +100,222 @@ type Profile struct {
 	User          User           `gorm:"foreignKey:UserID"`
 }
 
+// Swipe is a user's decision about another user. Swipes always target a user ID,
+// the same ID discovery returns for each candidate.
 type Swipe struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null"`
+	TargetUserID uuid.UUID `gorm:"type:uuid;not null"`
+	SwipeType    string    `gorm:"not null"`
+	SwipeDate    time.Time `gorm:"not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	User         User `gorm:"foreignKey:UserID"`
+	TargetUser   User `gorm:"foreignKey:TargetUserID"`
+}
+
+// SwipeQuota counts the swipes a user made on a quota day
+type SwipeQuota struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Day       time.Time `gorm:"type:date;primaryKey"`
+	Used      int       `gorm:"not null"`
+	SuperUsed int       `gorm:"not null"` // super likes have their own allowance
+}
+
+// TableName pins the table name; GORM treats "quota" as already plural and would
+// map the model to swipe_quota
+func (SwipeQuota) TableName() string {
+	return "swipe_quotas"
+}
+
+// SwipeUndo records a swipe the user took back, counted against their daily rewinds
+type SwipeUndo struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null"`
+	TargetUserID uuid.UUID `gorm:"type:uuid;not null"`
+	SwipeType    string    `gorm:"not null"`
+	SwipedAt     time.Time `gorm:"not null"`
+	Day          time.Time `gorm:"type:date;not null"`
+	CreatedAt    time.Time
+}
+
+// Swipe types; a super like is a like that is surfaced prominently to its recipient
+const (
+	SwipeTypePass      = "pass"
+	SwipeTypeLike      = "like"
+	SwipeTypeSuperLike = "super_like"
+)
+
+// IdempotencyKey stores the response to a request sent with an Idempotency-Key
+// header, so a retry of the same request replays it instead of running again
+type IdempotencyKey struct {
+	UserID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Key          string    `gorm:"primaryKey"`
+	Method       string    `gorm:"not null"`
+	Path         string    `gorm:"not null"`
+	RequestHash  string    `gorm:"not null"`
+	StatusCode   int       `gorm:"not null"`
+	ResponseBody []byte    `gorm:"not null"`
+	CreatedAt    time.Time
+	// CompletedAt is nil while the first request with the key is still running
+	CompletedAt *time.Time
+}
+
+// UserEvent is an event delivered to a user, kept for a short while so a client that
+// lost its event stream can resume it
+type UserEvent struct {
+	ID        int64     `gorm:"primaryKey"`
 	UserID    uuid.UUID `gorm:"type:uuid;not null"`
-	ProfileID uuid.UUID `gorm:"type:uuid;not null"`
-	IsLike    bool      `gorm:"not null"`
-	SwipeDate time.Time `gorm:"not null"`
+	Type      string    `gorm:"not null"`
+	Data      JSON      `gorm:"type:jsonb;not null"`
+	CreatedAt time.Time
+}
+
+// Session is a login session. Every refresh token issued for it belongs to the same
+// token family, so revoking the session invalidates all of them at once.
+type Session struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	RevokedAt *time.Time
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	User      User    `gorm:"foreignKey:UserID"`
-	Profile   Profile `gorm:"foreignKey:ProfileID"`
+	User      User `gorm:"foreignKey:UserID"`
+}
+
+// RefreshToken is a single-use token exchanged for a new access token. Only its
+// SHA-256 hash is stored.
+type RefreshToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	SessionID uuid.UUID `gorm:"type:uuid;not null;index"`
+	TokenHash string    `gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+	Session   Session `gorm:"foreignKey:SessionID"`
+}
+
+// Match records a mutual like between two users. The pair is stored in a
+// canonical order (UserAID < UserBID) so each couple can only match once.
+type Match struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserAID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_matches_pair"`
+	UserBID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_matches_pair;index"`
+	MatchedAt time.Time `gorm:"not null"`
+	Status    string    `gorm:"not null;default:active"`
+	// EndedByID and EndedAt record who unmatched or blocked, and when
+	EndedByID *uuid.UUID `gorm:"type:uuid"`
+	EndedAt   *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserA     User `gorm:"foreignKey:UserAID"`
+	UserB     User `gorm:"foreignKey:UserBID"`
+}
+
+// Match statuses. A match starts active and ends once, when either user unmatches
+// or blocks the other; an ended match is never reactivated.
+const (
+	MatchStatusActive    = "active"
+	MatchStatusUnmatched = "unmatched"
+	MatchStatusBlocked   = "blocked"
+)
+
+// Block hides two users from each other: neither sees the other in discovery or likes,
+// and their match, if any, ends
+type Block struct {
+	BlockerID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	BlockedID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CreatedAt time.Time
+}
+
+// Reasons a hidden profile was hidden for
+const (
+	HiddenReasonReports   = "reports"
+	HiddenReasonModerator = "moderator"
+)
+
+const (
+	ReportStatusPending  = "pending"
+	ReportStatusInReview = "in_review"
+	ReportStatusResolved = "resolved"
+)
+
+// Report reason categories
+const (
+	ReportReasonSpam          = "spam"
+	ReportReasonHarassment    = "harassment"
+	ReportReasonInappropriate = "inappropriate_content"
+	ReportReasonFakeProfile   = "fake_profile"
+	ReportReasonUnderage      = "underage"
+	ReportReasonOther         = "other"
+)
+
+// Report is a user's complaint about another user, reviewed by a moderator who
+// claims it from the queue and resolves it with a decision
+type Report struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ReporterID  uuid.UUID  `gorm:"type:uuid;not null"`
+	ReportedID  uuid.UUID  `gorm:"type:uuid;not null"`
+	Reason      string     `gorm:"not null"`
+	Details     string     `gorm:"not null;default:''"`
+	Status      string     `gorm:"not null;default:pending"`
+	ClaimedByID *uuid.UUID `gorm:"type:uuid"`
+	ClaimedAt   *time.Time
+	ResolvedAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// IsOpen reports whether the report still awaits a decision
+func (r *Report) IsOpen() bool {
+	return r.Status == ReportStatusPending || r.Status == ReportStatusInReview
+}
+
+// Actions a moderator can resolve a report with
+const (
+	ModerationActionDismiss     = "dismiss"
+	ModerationActionWarn        = "warn"
+	ModerationActionHideProfile = "hide_profile"
+)
+
+// ModerationDecision records how a moderator resolved a report. Decisions are never
+// changed or deleted; the database rejects updates and deletes.
+type ModerationDecision struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ReportID    uuid.UUID `gorm:"type:uuid;not null"`
+	ModeratorID uuid.UUID `gorm:"type:uuid;not null"`
+	ReportedID  uuid.UUID `gorm:"type:uuid;not null"`
+	Action      string    `gorm:"not null"`
+	Note        string    `gorm:"not null;default:''"`
+	CreatedAt   time.Time
+}
+
+// Conversation is the message thread of a match. Its users can only read and write
+// it while the match is active.
+type Conversation struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	MatchID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	LastMessageAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Match         Match `gorm:"foreignKey:MatchID"`
+}
+
+// Message is a message sent in a conversation. Deleting it only hides it.
+type Message struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ConversationID uuid.UUID `gorm:"type:uuid;not null"`
+	SenderID       uuid.UUID `gorm:"type:uuid;not null"`
+	Body           string    `gorm:"not null"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+// ConversationRead records up to when a user has read a conversation
+type ConversationRead struct {
+	ConversationID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	LastReadAt     time.Time `gorm:"not null"`
 }
 
 type PremiumPackage struct {
@@ -49,21 +323,81 @@ type PremiumPackage struct {
 	PackageName string    `gorm:"not null"`
 	Description string
 	Price       decimal.Decimal `gorm:"type:decimal(10,2);not null"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	// DurationMonths is the billing period of the package; 0 means lifetime
+	DurationMonths int `gorm:"not null;default:0"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt       `gorm:"index"`
+	Entitlements   []PackageEntitlement `gorm:"foreignKey:PackageID"`
+}
+
+// IsLifetime reports whether a subscription to the package never expires
+func (p *PremiumPackage) IsLifetime() bool {
+	return p.DurationMonths == 0
 }
 
+// Feature keys a premium package can grant
+const (
+	// FeatureUnlimitedSwipes lifts the daily swipe quota; with a limit it raises the quota to that value instead
+	FeatureUnlimitedSwipes = "unlimited_swipes"
+	FeatureVerifiedBadge   = "verified_badge"
+	FeatureSeeWhoLikedYou  = "see_who_liked_you"
+	// FeatureRewind allows undoing the last swipe; its limit is the number of undos per day
+	FeatureRewind = "rewind"
+	// FeatureSuperLikes raises the daily super like allowance to its limit, or lifts it without one
+	FeatureSuperLikes = "super_likes"
+)
+
+// Features lists every feature key packages may declare
+var Features = []string{
+	FeatureUnlimitedSwipes,
+	FeatureVerifiedBadge,
+	FeatureSeeWhoLikedYou,
+	FeatureRewind,
+	FeatureSuperLikes,
+}
+
+// PackageEntitlement is a feature unlocked by a premium package
+type PackageEntitlement struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	PackageID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_package_entitlements_feature"`
+	Feature   string    `gorm:"not null;uniqueIndex:idx_package_entitlements_feature"`
+	// Limit caps how much of the feature can be used per day; nil means unlimited
+	Limit     *int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+const (
+	SubscriptionStatusActive  = "active"
+	SubscriptionStatusExpired = "expired"
+)
+
 type UserPremium struct {
 	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	UserID       uuid.UUID `gorm:"type:uuid;not null"`
 	PackageID    uuid.UUID `gorm:"type:uuid;not null"`
 	PurchaseDate time.Time `gorm:"not null"`
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	DeletedAt    gorm.DeletedAt `gorm:"index"`
-	User         User           `gorm:"foreignKey:UserID"`
-	Package      PremiumPackage `gorm:"foreignKey:PackageID"`
+	// StartDate is later than PurchaseDate when the purchase was stacked onto a running subscription
+	StartDate time.Time `gorm:"not null"`
+	// ExpiresAt is nil for lifetime subscriptions
+	ExpiresAt *time.Time
+	// ExpiryNotifiedAt is when the user was warned that the subscription is about to expire
+	ExpiryNotifiedAt *time.Time
+	Status           string `gorm:"not null;default:active"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+	User             User           `gorm:"foreignKey:UserID"`
+	Package          PremiumPackage `gorm:"foreignKey:PackageID"`
+}
+
+// IsActiveAt reports whether the subscription grants premium at the given time
+func (up *UserPremium) IsActiveAt(t time.Time) bool {
+	if up.Status != SubscriptionStatusActive || up.StartDate.After(t) {
+		return false
+	}
+	return up.ExpiresAt == nil || up.ExpiresAt.After(t)
 }
 
 // BeforeCreate hook to set UUIDs before creation
@@ -88,6 +422,80 @@ func (s *Swipe) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (su *SwipeUndo) BeforeCreate(tx *gorm.DB) error {
+	if su.ID == uuid.Nil {
+		su.ID = uuid.New()
+	}
+	return nil
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (rt *RefreshToken) BeforeCreate(tx *gorm.DB) error {
+	if rt.ID == uuid.Nil {
+		rt.ID = uuid.New()
+	}
+	return nil
+}
+
+func (m *Match) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsLike reports whether the swipe is a like or a super like
+func (s *Swipe) IsLike() bool {
+	return s.SwipeType != SwipeTypePass
+}
+
+// IsActive reports whether the match has not been ended
+func (m *Match) IsActive() bool {
+	return m.Status == MatchStatusActive
+}
+
+func (c *Conversation) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (m *Message) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *Report) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (md *ModerationDecision) BeforeCreate(tx *gorm.DB) error {
+	if md.ID == uuid.Nil {
+		md.ID = uuid.New()
+	}
+	return nil
+}
+
+// OtherUserID returns the ID of the participant that is not userID
+func (m *Match) OtherUserID(userID uuid.UUID) uuid.UUID {
+	if m.UserAID == userID {
+		return m.UserBID
+	}
+	return m.UserAID
+}
+
 func (p *PremiumPackage) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
 		p.ID = uuid.New()
@@ -95,6 +503,13 @@ func (p *PremiumPackage) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (pe *PackageEntitlement) BeforeCreate(tx *gorm.DB) error {
+	if pe.ID == uuid.Nil {
+		pe.ID = uuid.New()
+	}
+	return nil
+}
+
 func (up *UserPremium) BeforeCreate(tx *gorm.DB) error {
 	if up.ID == uuid.Nil {
 		up.ID = uuid.New()
@@ -3,12 +3,23 @@ package models
 import "github.com/google/uuid"
 
 type SignUpRequest struct {
-	Email         string `json:"email"`
-	Password      string `json:"password"`
-	Username      string `json:"username"`
-	ProfilePicURL string `json:"profilePicURL"`
-	Bio           string `json:"bio"`
-	Interests     string `json:"interests"`
+	Email         string   `json:"email" binding:"required,email"`
+	Password      string   `json:"password" binding:"required"`
+	Username      string   `json:"username" binding:"required,min=3,max=30"`
+	ProfilePicURL string   `json:"profilePicURL" binding:"omitempty,url,max=2048"`
+	Bio           string   `json:"bio" binding:"max=500"`
+	Interests     []string `json:"interests" binding:"max=10,dive,min=1,max=30"`
+	// TimeZone is an IANA zone name such as "Europe/Paris"; it defaults to UTC
+	TimeZone string `json:"timeZone" binding:"omitempty,timezone"`
+}
+
+// UpdateProfileRequest only changes the fields present in the request body
+type UpdateProfileRequest struct {
+	Username      *string   `json:"username" binding:"omitempty,min=3,max=30"`
+	ProfilePicURL *string   `json:"profilePicURL" binding:"omitempty,url,max=2048"`
+	Bio           *string   `json:"bio" binding:"omitempty,max=500"`
+	Interests     *[]string `json:"interests" binding:"omitempty,max=10,dive,min=1,max=30"`
+	TimeZone      *string   `json:"timeZone" binding:"omitempty,timezone"`
 }
 
 type LoginRequest struct {
@@ -16,17 +27,51 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 type PremiumPackageRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description" binding:"required"`
 	Price       string `json:"price" binding:"required"`
+	// DurationMonths is the billing period; 0 (or omitted) creates a lifetime package
+	DurationMonths int                  `json:"duration_months" binding:"min=0"`
+	Entitlements   []EntitlementRequest `json:"entitlements" binding:"dive"`
+}
+
+type EntitlementRequest struct {
+	Feature string `json:"feature" binding:"required"`
+	Limit   *int   `json:"limit" binding:"omitempty,min=1"`
 }
 
 type PurchaseRequest struct {
-	UserID    string `json:"user_id" binding:"required"`
 	PackageID string `json:"package_id" binding:"required"`
 }
 
 type SwipeRequest struct {
-	ProfileID uuid.UUID `json:"profile_id" binding:"required"`
+	TargetUserID uuid.UUID `json:"target_user_id" binding:"required"`
+}
+
+type ChangeSwipeRequest struct {
+	Action string `json:"action" binding:"required,oneof=like pass"`
+}
+
+type SendMessageRequest struct {
+	Body string `json:"body" binding:"required,max=2000"`
+}
+
+type ReportRequest struct {
+	ReportedUserID uuid.UUID `json:"reported_user_id" binding:"required"`
+	Reason         string    `json:"reason" binding:"required,oneof=spam harassment inappropriate_content fake_profile underage other"`
+	Details        string    `json:"details" binding:"max=2000"`
+}
+
+type ResolveReportRequest struct {
+	Action string `json:"action" binding:"required,oneof=dismiss warn hide_profile"`
+	Note   string `json:"note" binding:"max=2000"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
 }
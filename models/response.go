@@ -1,6 +1,10 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type SignUpResponse struct {
 	UserID uuid.UUID `json:"user_id"`
@@ -8,5 +12,292 @@ type SignUpResponse struct {
 
 type LoginResponse struct {
 	UserID uuid.UUID `json:"user_id"`
-	Token  string    `json:"token"`
+	// Token is the short-lived access token sent as "Authorization: Bearer <token>"
+	Token          string    `json:"token"`
+	TokenExpiresAt time.Time `json:"token_expires_at"`
+	// RefreshToken is single-use and exchanged at /auth/refresh for a new pair
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+type MatchResponse struct {
+	MatchID   uuid.UUID `json:"match_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	MatchedAt time.Time `json:"matched_at"`
+}
+
+type BlockResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+type ConversationResponse struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	MatchID        uuid.UUID `json:"match_id"`
+	// UserID is the other participant
+	UserID        uuid.UUID  `json:"user_id"`
+	LastMessageAt *time.Time `json:"last_message_at"`
+	UnreadCount   int        `json:"unread_count"`
+}
+
+type MessageResponse struct {
+	MessageID      uuid.UUID `json:"message_id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// LikeReceivedResponse tells a user that someone liked them. The liker stays hidden;
+// GET /likes/received reveals them to users entitled to see who liked them.
+type LikeReceivedResponse struct {
+	SuperLike bool      `json:"super_like"`
+	LikedAt   time.Time `json:"liked_at"`
+}
+
+// SubscriptionExpiringResponse warns a user that their premium is about to end
+type SubscriptionExpiringResponse struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	PackageID      uuid.UUID `json:"package_id"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// ReadReceiptResponse tells a user that the other participant read the conversation
+type ReadReceiptResponse struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	ReadAt         time.Time `json:"read_at"`
+}
+
+// TypingResponse tells a user that the other participant is typing
+type TypingResponse struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	UserID         uuid.UUID `json:"user_id"`
+}
+
+// MessagePage lists messages newest first
+type MessagePage struct {
+	Messages []MessageResponse `json:"messages"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func NewMessageResponse(message *Message) MessageResponse {
+	return MessageResponse{
+		MessageID:      message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		Body:           message.Body,
+		CreatedAt:      message.CreatedAt,
+	}
+}
+
+type SubscriptionResponse struct {
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	PackageID      uuid.UUID  `json:"package_id"`
+	StartDate      time.Time  `json:"start_date"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Status         string     `json:"status"`
+}
+
+func NewSubscriptionResponse(up *UserPremium) *SubscriptionResponse {
+	return &SubscriptionResponse{
+		SubscriptionID: up.ID,
+		PackageID:      up.PackageID,
+		StartDate:      up.StartDate,
+		ExpiresAt:      up.ExpiresAt,
+		Status:         up.Status,
+	}
+}
+
+type SwipeQuotaResponse struct {
+	Used      int  `json:"used"`
+	Limit     int  `json:"limit"`
+	Remaining int  `json:"remaining"`
+	Unlimited bool `json:"unlimited"`
+	// SuperLikes is the separate daily super like allowance, which resets at the same time
+	SuperLikes SuperLikeQuotaResponse `json:"super_likes"`
+	// TimeZone is the user's zone; the quota day runs from midnight to midnight there
+	TimeZone string    `json:"time_zone"`
+	ResetsAt time.Time `json:"resets_at"`
+}
+
+type SuperLikeQuotaResponse struct {
+	Used      int  `json:"used"`
+	Limit     int  `json:"limit"`
+	Remaining int  `json:"remaining"`
+	Unlimited bool `json:"unlimited"`
+}
+
+type EntitlementResponse struct {
+	Feature string `json:"feature"`
+	// Limit is omitted when the feature is unlimited
+	Limit *int `json:"limit,omitempty"`
+}
+
+type CandidateResponse struct {
+	UserID        uuid.UUID `json:"user_id"`
+	Username      string    `json:"username"`
+	ProfilePicURL string    `json:"profile_pic_url"`
+	IsVerified    bool      `json:"is_verified"`
+	SuperLikedYou bool      `json:"super_liked_you"`
+}
+
+type CandidatePage struct {
+	Candidates []CandidateResponse `json:"candidates"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func NewCandidateResponse(candidate *Candidate) CandidateResponse {
+	return CandidateResponse{
+		UserID:        candidate.ID,
+		Username:      candidate.Username,
+		ProfilePicURL: candidate.ProfilePicURL,
+		IsVerified:    candidate.IsVerified,
+		SuperLikedYou: candidate.SuperLikedYou,
+	}
+}
+
+// LikesReceivedResponse lists the unanswered likes a user received
+type LikesReceivedResponse struct {
+	Count int `json:"count"`
+	// Unlocked is set for users with the see who liked you entitlement; only they get Likes
+	Unlocked bool                   `json:"unlocked"`
+	Likes    []ReceivedLikeResponse `json:"likes,omitempty"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type ReceivedLikeResponse struct {
+	PublicProfileResponse
+	SuperLike bool      `json:"super_like"`
+	LikedAt   time.Time `json:"liked_at"`
+}
+
+func NewReceivedLikeResponse(swipe *Swipe, profile *Profile) ReceivedLikeResponse {
+	return ReceivedLikeResponse{
+		PublicProfileResponse: *NewPublicProfileResponse(profile),
+		SuperLike:             swipe.SwipeType == SwipeTypeSuperLike,
+		LikedAt:               swipe.CreatedAt,
+	}
+}
+
+// PublicProfileResponse is what other users can see of a profile
+type PublicProfileResponse struct {
+	UserID        uuid.UUID `json:"user_id"`
+	Username      string    `json:"username"`
+	ProfilePicURL string    `json:"profile_pic_url"`
+	IsVerified    bool      `json:"is_verified"`
+	Bio           string    `json:"bio"`
+	Interests     []string  `json:"interests"`
+}
+
+// ProfileResponse is the owner's view of their own profile
+type ProfileResponse struct {
+	PublicProfileResponse
+	Email    string `json:"email"`
+	TimeZone string `json:"time_zone"`
+	// Hidden is set while moderation hides the profile from other users
+	Hidden    bool      `json:"hidden"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewPublicProfileResponse(profile *Profile) *PublicProfileResponse {
+	interests := []string(profile.Interests)
+	if interests == nil {
+		interests = []string{}
+	}
+	return &PublicProfileResponse{
+		UserID:        profile.UserID,
+		Username:      profile.User.Username,
+		ProfilePicURL: profile.User.ProfilePicURL,
+		IsVerified:    profile.User.IsVerified,
+		Bio:           profile.Bio,
+		Interests:     interests,
+	}
+}
+
+func NewProfileResponse(profile *Profile) *ProfileResponse {
+	return &ProfileResponse{
+		PublicProfileResponse: *NewPublicProfileResponse(profile),
+		Email:                 profile.User.Email,
+		TimeZone:              profile.User.TimeZone,
+		Hidden:                profile.User.HiddenAt != nil,
+		CreatedAt:             profile.User.CreatedAt,
+	}
+}
+
+type ReportResponse struct {
+	ReportID       uuid.UUID  `json:"report_id"`
+	ReporterID     uuid.UUID  `json:"reporter_id"`
+	ReportedUserID uuid.UUID  `json:"reported_user_id"`
+	Reason         string     `json:"reason"`
+	Details        string     `json:"details"`
+	Status         string     `json:"status"`
+	ClaimedByID    *uuid.UUID `json:"claimed_by_id"`
+	ClaimedAt      *time.Time `json:"claimed_at"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func NewReportResponse(report *Report) ReportResponse {
+	return ReportResponse{
+		ReportID:       report.ID,
+		ReporterID:     report.ReporterID,
+		ReportedUserID: report.ReportedID,
+		Reason:         report.Reason,
+		Details:        report.Details,
+		Status:         report.Status,
+		ClaimedByID:    report.ClaimedByID,
+		ClaimedAt:      report.ClaimedAt,
+		ResolvedAt:     report.ResolvedAt,
+		CreatedAt:      report.CreatedAt,
+	}
+}
+
+// ReportPage lists the moderation queue oldest first
+type ReportPage struct {
+	Reports    []ReportResponse `json:"reports"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+type ModerationDecisionResponse struct {
+	DecisionID     uuid.UUID `json:"decision_id"`
+	ReportID       uuid.UUID `json:"report_id"`
+	ModeratorID    uuid.UUID `json:"moderator_id"`
+	ReportedUserID uuid.UUID `json:"reported_user_id"`
+	Action         string    `json:"action"`
+	Note           string    `json:"note"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func NewModerationDecisionResponse(decision *ModerationDecision) ModerationDecisionResponse {
+	return ModerationDecisionResponse{
+		DecisionID:     decision.ID,
+		ReportID:       decision.ReportID,
+		ModeratorID:    decision.ModeratorID,
+		ReportedUserID: decision.ReportedID,
+		Action:         decision.Action,
+		Note:           decision.Note,
+		CreatedAt:      decision.CreatedAt,
+	}
+}
+
+type RoleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+func NewRoleResponse(role *Role) RoleResponse {
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, p := range role.Permissions {
+		permissions = append(permissions, p.Name)
+	}
+	return RoleResponse{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+	}
 }
@@ -0,0 +1,290 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"datingApp/events"
+)
+
+const (
+	// writeWait is how long a write to the client may take
+	writeWait = 10 * time.Second
+	// pongWait is how long the client may stay silent before it is considered gone
+	pongWait = 60 * time.Second
+	// pingPeriod must be shorter than pongWait so pings keep a healthy connection alive
+	pingPeriod = pongWait * 9 / 10
+	// maxMessageSize caps the size of messages sent by the client
+	maxMessageSize = 4096
+	// sendBuffer is how many events may queue up for a client before it is dropped
+	sendBuffer = 64
+)
+
+// Client message types
+const (
+	messageTyping = "typing"
+	messageRead   = "read"
+)
+
+// InboundHandler acts on the messages clients send over their connection
+type InboundHandler interface {
+	Typing(userID, conversationID uuid.UUID) error
+	MarkRead(userID, conversationID uuid.UUID) error
+}
+
+// Hub tracks the WebSocket connections and event listeners of every user on this node
+// and pushes them the events published for that user on the bus. Streams of a revoked
+// session are closed.
+type Hub struct {
+	handler     InboundHandler
+	unsubscribe func()
+
+	mu      sync.Mutex
+	clients map[uuid.UUID]map[*client]struct{}
+	// listeners maps each listener of a user to the session it was opened with
+	listeners map[uuid.UUID]map[chan events.Event]uuid.UUID
+}
+
+// NewHub subscribes a hub to the bus
+func NewHub(bus events.Bus, handler InboundHandler) *Hub {
+	h := &Hub{
+		handler:   handler,
+		clients:   map[uuid.UUID]map[*client]struct{}{},
+		listeners: map[uuid.UUID]map[chan events.Event]uuid.UUID{},
+	}
+	h.unsubscribe = bus.Subscribe(h.deliver)
+	return h
+}
+
+// Serve runs the connection opened in the user's session until either side closes it
+// or the session is revoked
+func (h *Hub) Serve(conn *websocket.Conn, userID, sessionID uuid.UUID) {
+	c := &client{hub: h, conn: conn, userID: userID, sessionID: sessionID, send: make(chan []byte, sendBuffer)}
+	h.register(c)
+
+	go c.writePump()
+	c.readPump()
+}
+
+// Listen returns a channel receiving the events published for the user from now on,
+// and a function to stop listening. The channel is closed when the listener stops,
+// falls too far behind or its session is revoked.
+func (h *Hub) Listen(userID, sessionID uuid.UUID) (<-chan events.Event, func()) {
+	ch := make(chan events.Event, sendBuffer)
+
+	h.mu.Lock()
+	if h.listeners[userID] == nil {
+		h.listeners[userID] = map[chan events.Event]uuid.UUID{}
+	}
+	h.listeners[userID][ch] = sessionID
+	h.mu.Unlock()
+
+	return ch, func() { h.removeListener(userID, ch) }
+}
+
+// Close stops delivering events; open connections are left to time out
+func (h *Hub) Close() {
+	h.unsubscribe()
+}
+
+func (h *Hub) register(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.clients[c.userID] == nil {
+		h.clients[c.userID] = map[*client]struct{}{}
+	}
+	h.clients[c.userID][c] = struct{}{}
+}
+
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dropClient(c)
+}
+
+func (h *Hub) removeListener(userID uuid.UUID, ch chan events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dropListener(userID, ch)
+}
+
+// deliver queues the event on every connection and listener of its user. One whose
+// queue is full is too slow to keep up and is dropped rather than blocking the others.
+// Queuing never blocks, so it happens under the lock that guards closing the queues.
+func (h *Hub) deliver(event events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if event.Type == events.TypeSessionRevoked {
+		h.closeSession(event)
+		return
+	}
+
+	for ch := range h.listeners[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+			h.dropListener(event.UserID, ch)
+		}
+	}
+
+	conns := h.clients[event.UserID]
+	if len(conns) == 0 {
+		return
+	}
+	payload, err := json.Marshal(outboundEvent{ID: event.ID, Type: event.Type, Data: event.Data, CreatedAt: event.CreatedAt})
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", event.Type, err)
+		return
+	}
+	for c := range conns {
+		select {
+		case c.send <- payload:
+		default:
+			h.dropClient(c)
+		}
+	}
+}
+
+// closeSession drops the connections and listeners of the revoked session; the caller
+// holds the lock
+func (h *Hub) closeSession(event events.Event) {
+	var revoked events.SessionRevoked
+	if err := json.Unmarshal(event.Data, &revoked); err != nil {
+		log.Printf("Failed to decode %s event: %v", event.Type, err)
+		return
+	}
+	matches := func(sessionID uuid.UUID) bool {
+		return revoked.SessionID == nil || *revoked.SessionID == sessionID
+	}
+
+	for ch, sessionID := range h.listeners[event.UserID] {
+		if matches(sessionID) {
+			h.dropListener(event.UserID, ch)
+		}
+	}
+	for c := range h.clients[event.UserID] {
+		if matches(c.sessionID) {
+			h.dropClient(c)
+		}
+	}
+}
+
+// dropClient removes the client and closes its queue; the caller holds the lock
+func (h *Hub) dropClient(c *client) {
+	conns := h.clients[c.userID]
+	if _, ok := conns[c]; !ok {
+		return
+	}
+	delete(conns, c)
+	if len(conns) == 0 {
+		delete(h.clients, c.userID)
+	}
+	close(c.send)
+}
+
+// dropListener removes the listener and closes its channel; the caller holds the lock
+func (h *Hub) dropListener(userID uuid.UUID, ch chan events.Event) {
+	listeners := h.listeners[userID]
+	if _, ok := listeners[ch]; !ok {
+		return
+	}
+	delete(listeners, ch)
+	if len(listeners) == 0 {
+		delete(h.listeners, userID)
+	}
+	close(ch)
+}
+
+// outboundEvent is an event as sent to the client
+type outboundEvent struct {
+	ID        int64           `json:"id,omitempty"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// inboundMessage is a message sent by the client
+type inboundMessage struct {
+	Type           string    `json:"type"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+}
+
+type client struct {
+	hub       *Hub
+	conn      *websocket.Conn
+	userID    uuid.UUID
+	sessionID uuid.UUID
+	send      chan []byte
+}
+
+// readPump handles the client's messages and pongs until the connection fails
+func (c *client) readPump() {
+	defer func() {
+		c.hub.unregister(c)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var msg inboundMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("WebSocket of user %s closed: %v", c.userID, err)
+			}
+			return
+		}
+
+		var err error
+		switch msg.Type {
+		case messageTyping:
+			err = c.hub.handler.Typing(c.userID, msg.ConversationID)
+		case messageRead:
+			err = c.hub.handler.MarkRead(c.userID, msg.ConversationID)
+		default:
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to handle %s message of user %s: %v", msg.Type, c.userID, err)
+		}
+	}
+}
+
+// writePump sends queued events and periodic pings until the send queue is closed
+func (c *client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case payload, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
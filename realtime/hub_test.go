@@ -0,0 +1,50 @@
+package realtime
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"datingApp/events"
+)
+
+func TestHubClosesListenersOfRevokedSession(t *testing.T) {
+	bus := events.NewLocalBus()
+	hub := NewHub(bus, nil)
+	defer hub.Close()
+
+	userID, revokedID, otherID := uuid.New(), uuid.New(), uuid.New()
+	revoked, stopRevoked := hub.Listen(userID, revokedID)
+	defer stopRevoked()
+	other, stopOther := hub.Listen(userID, otherID)
+	defer stopOther()
+
+	event, err := events.New(userID, events.TypeSessionRevoked, events.SessionRevoked{SessionID: &revokedID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(event); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := <-revoked; ok {
+		t.Error("the listener of the revoked session received an event instead of being closed")
+	}
+	select {
+	case event, ok := <-other:
+		t.Errorf("the listener of another session got %+v (open: %t)", event, ok)
+	default:
+	}
+
+	// Revoking every session closes the remaining listener
+	event, err = events.New(userID, events.TypeSessionRevoked, events.SessionRevoked{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(event); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-other; ok {
+		t.Error("the listener was not closed when all sessions were revoked")
+	}
+}
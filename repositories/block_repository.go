@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"datingApp/models"
+)
+
+type BlockRepository interface {
+	BlockUser(blockerID, blockedID uuid.UUID) error
+	UnblockUser(blockerID, blockedID uuid.UUID) (bool, error)
+	GetBlockedUsers(blockerID uuid.UUID) ([]models.Block, error)
+	IsBlocked(a, b uuid.UUID) (bool, error)
+}
+
+type BlockRepo struct {
+	DB *gorm.DB
+}
+
+func NewBlockRepo(db *gorm.DB) *BlockRepo {
+	return &BlockRepo{DB: db}
+}
+
+// notBlockedWith excludes the users, identified by the given column, who blocked the
+// user bound to both placeholders or were blocked by them
+func notBlockedWith(column string) string {
+	return `NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocks.blocker_id = ? AND blocks.blocked_id = ` + column + `)
+		   OR (blocks.blocked_id = ? AND blocks.blocker_id = ` + column + `)
+	)`
+}
+
+// BlockUser records the block and ends the pair's active match, if any, with the
+// blocked status. Blocking a user twice is a no-op.
+func (r *BlockRepo) BlockUser(blockerID, blockedID uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialize with likes between the pair so no match is created after the block
+		if err := lockPair(tx, blockerID, blockedID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		block := &models.Block{BlockerID: blockerID, BlockedID: blockedID, CreatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(block).Error; err != nil {
+			return err
+		}
+
+		userA, userB := orderPair(blockerID, blockedID)
+		return tx.Model(&models.Match{}).
+			Where("user_a_id = ? AND user_b_id = ? AND status = ?", userA, userB, models.MatchStatusActive).
+			Updates(map[string]interface{}{
+				"status":      models.MatchStatusBlocked,
+				"ended_by_id": blockerID,
+				"ended_at":    now,
+			}).Error
+	})
+}
+
+// UnblockUser removes the block and returns false when there was none. An ended
+// match stays ended.
+func (r *BlockRepo) UnblockUser(blockerID, blockedID uuid.UUID) (bool, error) {
+	result := r.DB.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&models.Block{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetBlockedUsers returns the blocks made by the user, newest first
+func (r *BlockRepo) GetBlockedUsers(blockerID uuid.UUID) ([]models.Block, error) {
+	var blocks []models.Block
+	err := r.DB.Where("blocker_id = ?", blockerID).Order("created_at DESC").Find(&blocks).Error
+	return blocks, err
+}
+
+// IsBlocked reports whether either user blocked the other
+func (r *BlockRepo) IsBlocked(a, b uuid.UUID) (bool, error) {
+	return blockedBetween(r.DB, a, b)
+}
+
+func blockedBetween(db *gorm.DB, a, b uuid.UUID) (bool, error) {
+	var count int64
+	err := db.Model(&models.Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", a, b, b, a).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"datingApp/models"
+)
+
+func TestBlockUserEndsMatch(t *testing.T) {
+	db := testDB(t)
+	blocks, swipes := NewBlockRepo(db), NewSwipeRepo(db)
+	alice, bob := createTestUser(t, db), createTestUser(t, db)
+	charge := QuotaCharge{Day: time.Now()}
+
+	if _, err := swipes.RecordSwipe(alice.ID, bob.ID, models.SwipeTypeLike, nil, charge); err != nil {
+		t.Fatal(err)
+	}
+	match, err := swipes.RecordSwipe(bob.ID, alice.ID, models.SwipeTypeLike, nil, charge)
+	if err != nil || match == nil {
+		t.Fatalf("RecordSwipe = %v, %v, want a match", match, err)
+	}
+
+	if err := blocks.BlockUser(bob.ID, alice.ID); err != nil {
+		t.Fatalf("BlockUser: %v", err)
+	}
+	// Blocking twice is a no-op
+	if err := blocks.BlockUser(bob.ID, alice.ID); err != nil {
+		t.Fatalf("blocking again: %v", err)
+	}
+
+	stored, err := NewMatchRepo(db).GetMatchByID(match.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("GetMatchByID = %v, %v", stored, err)
+	}
+	if stored.Status != models.MatchStatusBlocked || stored.EndedByID == nil || *stored.EndedByID != bob.ID {
+		t.Errorf("match is %s, ended by %v; want blocked by %s", stored.Status, stored.EndedByID, bob.ID)
+	}
+	for _, pair := range [][2]*models.User{{alice, bob}, {bob, alice}} {
+		blocked, err := blocks.IsBlocked(pair[0].ID, pair[1].ID)
+		if err != nil || !blocked {
+			t.Errorf("IsBlocked(%s, %s) = %t, %v, want true", pair[0].ID, pair[1].ID, blocked, err)
+		}
+	}
+
+	// The pair has no active match left
+	if matches, err := NewMatchRepo(db).GetMatchesForUser(alice.ID); err != nil || len(matches) != 0 {
+		t.Errorf("alice's active matches = %v, %v, want none", matches, err)
+	}
+
+	removed, err := blocks.UnblockUser(bob.ID, alice.ID)
+	if err != nil || !removed {
+		t.Fatalf("UnblockUser = %t, %v, want true", removed, err)
+	}
+	if removed, err := blocks.UnblockUser(bob.ID, alice.ID); err != nil || removed {
+		t.Errorf("unblocking again = %t, %v, want false", removed, err)
+	}
+	// The match stays ended after the block is lifted
+	stored, err = NewMatchRepo(db).GetMatchByID(match.ID)
+	if err != nil || stored == nil || stored.Status != models.MatchStatusBlocked {
+		t.Errorf("match after unblocking = %+v, %v, want still blocked", stored, err)
+	}
+}
@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"datingApp/models"
+)
+
+type ConversationRepository interface {
+	GetConversationsForUser(userID uuid.UUID) ([]models.Conversation, error)
+	GetConversationByID(conversationID uuid.UUID) (*models.Conversation, error)
+	CountUnread(userID uuid.UUID, conversationIDs []uuid.UUID) (map[uuid.UUID]int, error)
+	CreateMessage(message *models.Message) error
+	GetMessages(conversationID uuid.UUID, after *models.Cursor, limit int) ([]models.Message, error)
+	GetMessageByID(messageID uuid.UUID) (*models.Message, error)
+	DeleteMessage(messageID uuid.UUID) error
+	MarkRead(conversationID, userID uuid.UUID, at time.Time) error
+}
+
+type ConversationRepo struct {
+	DB *gorm.DB
+}
+
+func NewConversationRepo(db *gorm.DB) *ConversationRepo {
+	return &ConversationRepo{DB: db}
+}
+
+// GetConversationsForUser returns the conversations of the user's active matches, with
+// the match loaded, most recently active first
+func (r *ConversationRepo) GetConversationsForUser(userID uuid.UUID) ([]models.Conversation, error) {
+	var conversations []models.Conversation
+	err := r.DB.InnerJoins("Match").
+		Where(`("Match".user_a_id = ? OR "Match".user_b_id = ?) AND "Match".status = ?`,
+			userID, userID, models.MatchStatusActive).
+		Order("COALESCE(conversations.last_message_at, conversations.created_at) DESC, conversations.id").
+		Find(&conversations).Error
+	return conversations, err
+}
+
+// GetConversationByID retrieves a conversation with its match, returning nil when it does not exist
+func (r *ConversationRepo) GetConversationByID(conversationID uuid.UUID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.DB.InnerJoins("Match").Where("conversations.id = ?", conversationID).First(&conversation).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// CountUnread returns, per conversation, how many messages from the other participant
+// the user has not read yet. Conversations without unread messages are left out.
+func (r *ConversationRepo) CountUnread(userID uuid.UUID, conversationIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		ConversationID uuid.UUID
+		Count          int
+	}
+	err := r.DB.Model(&models.Message{}).
+		Select("messages.conversation_id, COUNT(*) AS count").
+		Joins(`LEFT JOIN conversation_reads ON conversation_reads.conversation_id = messages.conversation_id
+			AND conversation_reads.user_id = ?`, userID).
+		Where("messages.conversation_id IN ? AND messages.sender_id <> ?", conversationIDs, userID).
+		Where("conversation_reads.last_read_at IS NULL OR messages.created_at > conversation_reads.last_read_at").
+		Group("messages.conversation_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		counts[row.ConversationID] = row.Count
+	}
+	return counts, nil
+}
+
+// CreateMessage stores the message and bumps the conversation's last activity. The
+// sender has read everything up to their own message.
+func (r *ConversationRepo) CreateMessage(message *models.Message) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.Conversation{}).
+			Where("id = ?", message.ConversationID).
+			Update("last_message_at", message.CreatedAt).Error
+		if err != nil {
+			return err
+		}
+		return markRead(tx, message.ConversationID, message.SenderID, message.CreatedAt)
+	})
+}
+
+// GetMessages returns up to limit messages of the conversation, newest first by
+// (created_at, id) and starting after the cursor. Deleted messages are left out.
+func (r *ConversationRepo) GetMessages(conversationID uuid.UUID, after *models.Cursor, limit int) ([]models.Message, error) {
+	var messages []models.Message
+	query := r.DB.Where("conversation_id = ?", conversationID)
+	if after != nil {
+		query = query.Where("(created_at, id) < (?, ?)", after.CreatedAt, after.ID)
+	}
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+// GetMessageByID retrieves a message that was not deleted, returning nil when there is none
+func (r *ConversationRepo) GetMessageByID(messageID uuid.UUID) (*models.Message, error) {
+	var message models.Message
+	err := r.DB.Where("id = ?", messageID).First(&message).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// DeleteMessage soft deletes a message
+func (r *ConversationRepo) DeleteMessage(messageID uuid.UUID) error {
+	return r.DB.Where("id = ?", messageID).Delete(&models.Message{}).Error
+}
+
+// MarkRead records that the user has read the conversation up to the given time
+func (r *ConversationRepo) MarkRead(conversationID, userID uuid.UUID, at time.Time) error {
+	return markRead(r.DB, conversationID, userID, at)
+}
+
+// markRead moves the user's read marker forward, never back
+func markRead(db *gorm.DB, conversationID, userID uuid.UUID, at time.Time) error {
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "conversation_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_read_at": gorm.Expr("GREATEST(conversation_reads.last_read_at, excluded.last_read_at)"),
+		}),
+	}).Create(&models.ConversationRead{
+		ConversationID: conversationID,
+		UserID:         userID,
+		LastReadAt:     at,
+	}).Error
+}
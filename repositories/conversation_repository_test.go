@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"datingApp/models"
+)
+
+func TestCountUnreadFollowsReadMarker(t *testing.T) {
+	db := testDB(t)
+	swipes, conversations := NewSwipeRepo(db), NewConversationRepo(db)
+	alice, bob := createTestUser(t, db), createTestUser(t, db)
+	charge := QuotaCharge{Day: time.Now()}
+
+	if _, err := swipes.RecordSwipe(alice.ID, bob.ID, models.SwipeTypeLike, nil, charge); err != nil {
+		t.Fatal(err)
+	}
+	match, err := swipes.RecordSwipe(bob.ID, alice.ID, models.SwipeTypeLike, nil, charge)
+	if err != nil || match == nil {
+		t.Fatalf("mutual like = %v, %v; want a match", match, err)
+	}
+	list, err := conversations.GetConversationsForUser(alice.ID)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("GetConversationsForUser = %+v, %v; want the match's conversation", list, err)
+	}
+	conversationID := list[0].ID
+	ids := []uuid.UUID{conversationID}
+
+	sentAt := time.Now().Add(-time.Hour)
+	for i, sender := range []uuid.UUID{alice.ID, alice.ID, bob.ID} {
+		message := &models.Message{ConversationID: conversationID, SenderID: sender, Body: "message",
+			CreatedAt: sentAt.Add(time.Duration(i) * time.Minute)}
+		if err := conversations.CreateMessage(message); err != nil {
+			t.Fatalf("CreateMessage: %v", err)
+		}
+	}
+
+	unread := func(userID uuid.UUID) int {
+		t.Helper()
+		counts, err := conversations.CountUnread(userID, ids)
+		if err != nil {
+			t.Fatalf("CountUnread: %v", err)
+		}
+		return counts[conversationID]
+	}
+	// Replying marks everything before the reply as read
+	if got := unread(bob.ID); got != 0 {
+		t.Errorf("bob's unread after replying = %d, want 0", got)
+	}
+	if got := unread(alice.ID); got != 1 {
+		t.Errorf("alice's unread = %d, want 1", got)
+	}
+
+	if err := conversations.MarkRead(conversationID, alice.ID, time.Now()); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	// An older read marker never moves the existing one back
+	if err := conversations.MarkRead(conversationID, alice.ID, sentAt); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	if got := unread(alice.ID); got != 0 {
+		t.Errorf("alice's unread after reading = %d, want 0", got)
+	}
+
+	// Ending the match closes the conversation for both users
+	if _, err := NewMatchRepo(db).EndMatch(match.ID, alice.ID, models.MatchStatusUnmatched); err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []uuid.UUID{alice.ID, bob.ID} {
+		if list, err := conversations.GetConversationsForUser(userID); err != nil || len(list) != 0 {
+			t.Errorf("conversations after unmatching = %+v, %v; want none", list, err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"datingApp/models"
+)
+
+type EventRepository interface {
+	AppendUserEvent(event *models.UserEvent, deliver func() error) error
+	GetUserEventsAfter(userID uuid.UUID, afterID int64, limit int) ([]models.UserEvent, error)
+	DeleteUserEventsBefore(before time.Time) (int64, error)
+}
+
+type EventRepo struct {
+	DB *gorm.DB
+}
+
+func NewEventRepo(db *gorm.DB) *EventRepo {
+	return &EventRepo{DB: db}
+}
+
+// AppendUserEvent appends an event to the user's log, assigning its ID, and calls
+// deliver before the next event of the user can be appended. A user's events are
+// thus logged and delivered in ID order: a client that received an event has every
+// earlier one, and resuming after its ID cannot skip one still being written. The
+// event stays logged when delivery fails, and the delivery error is returned.
+func (r *EventRepo) AppendUserEvent(event *models.UserEvent, deliver func() error) error {
+	var deliverErr error
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "events:"+event.UserID.String()).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		deliverErr = deliver()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return deliverErr
+}
+
+// GetUserEventsAfter returns the user's logged events with an ID above afterID, oldest first
+func (r *EventRepo) GetUserEventsAfter(userID uuid.UUID, afterID int64, limit int) ([]models.UserEvent, error) {
+	var userEvents []models.UserEvent
+	err := r.DB.Where("user_id = ? AND id > ?", userID, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&userEvents).Error
+	return userEvents, err
+}
+
+// DeleteUserEventsBefore removes the events logged before the given time
+func (r *EventRepo) DeleteUserEventsBefore(before time.Time) (int64, error) {
+	result := r.DB.Where("created_at < ?", before).Delete(&models.UserEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"datingApp/models"
+)
+
+func TestAppendUserEventDeliversInIDOrder(t *testing.T) {
+	db := testDB(t)
+	repo := NewEventRepo(db)
+	user := createTestUser(t, db)
+
+	var mu sync.Mutex
+	var delivered []int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			event := &models.UserEvent{UserID: user.ID, Type: "like.received", Data: models.JSON(`{}`), CreatedAt: time.Now()}
+			err := repo.AppendUserEvent(event, func() error {
+				mu.Lock()
+				defer mu.Unlock()
+				delivered = append(delivered, event.ID)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("AppendUserEvent: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 1; i < len(delivered); i++ {
+		if delivered[i] <= delivered[i-1] {
+			t.Fatalf("events were delivered out of order: %v", delivered)
+		}
+	}
+
+	// A failed delivery is reported, but the event stays logged for a resuming client
+	failed := errors.New("bus is down")
+	event := &models.UserEvent{UserID: user.ID, Type: "like.received", Data: models.JSON(`{}`), CreatedAt: time.Now()}
+	if err := repo.AppendUserEvent(event, func() error { return failed }); !errors.Is(err, failed) {
+		t.Errorf("AppendUserEvent error = %v, want %v", err, failed)
+	}
+	logged, err := repo.GetUserEventsAfter(user.ID, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 21 || logged[20].ID != event.ID {
+		t.Errorf("%d events logged, want 21 ending with %d", len(logged), event.ID)
+	}
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"datingApp/models"
+)
+
+type IdempotencyRepository interface {
+	ReserveIdempotencyKey(record *models.IdempotencyKey, expiredBefore, abandonedBefore time.Time) (bool, error)
+	GetIdempotencyKey(userID uuid.UUID, key string, since time.Time) (*models.IdempotencyKey, error)
+	CompleteIdempotencyKey(record *models.IdempotencyKey) error
+	ReleaseIdempotencyKey(record *models.IdempotencyKey) error
+	DeleteIdempotencyKeysBefore(before time.Time) (int64, error)
+}
+
+type IdempotencyRepo struct {
+	DB *gorm.DB
+}
+
+func NewIdempotencyRepo(db *gorm.DB) *IdempotencyRepo {
+	return &IdempotencyRepo{DB: db}
+}
+
+// GetIdempotencyKey returns the record stored for the user's key since the given time,
+// or nil when there is none. The record is pending while CompletedAt is nil.
+func (r *IdempotencyRepo) GetIdempotencyKey(userID uuid.UUID, key string, since time.Time) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	err := r.DB.Where("user_id = ? AND key = ? AND created_at >= ?", userID, key, since).First(&record).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ReserveIdempotencyKey stores a pending record for a key before its request runs and
+// reports whether it did. The key is only taken over when its record expired before
+// expiredBefore, or is still pending since before abandonedBefore; otherwise another
+// request holds it and nothing is stored.
+func (r *IdempotencyRepo) ReserveIdempotencyKey(record *models.IdempotencyKey, expiredBefore, abandonedBefore time.Time) (bool, error) {
+	result := r.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"method", "path", "request_hash", "status_code", "response_body", "created_at", "completed_at",
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{clause.Expr{
+			SQL:  "idempotency_keys.created_at < ? OR (idempotency_keys.completed_at IS NULL AND idempotency_keys.created_at < ?)",
+			Vars: []interface{}{expiredBefore, abandonedBefore},
+		}}},
+	}).Create(record)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CompleteIdempotencyKey stores the response of a reserved key so retries replay it
+func (r *IdempotencyRepo) CompleteIdempotencyKey(record *models.IdempotencyKey) error {
+	return r.DB.Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND key = ? AND created_at = ? AND completed_at IS NULL", record.UserID, record.Key, record.CreatedAt).
+		Updates(map[string]interface{}{
+			"status_code":   record.StatusCode,
+			"response_body": record.ResponseBody,
+			"completed_at":  record.CompletedAt,
+		}).Error
+}
+
+// ReleaseIdempotencyKey drops a reservation whose request failed, so it can be retried
+func (r *IdempotencyRepo) ReleaseIdempotencyKey(record *models.IdempotencyKey) error {
+	return r.DB.Where("user_id = ? AND key = ? AND created_at = ? AND completed_at IS NULL", record.UserID, record.Key, record.CreatedAt).
+		Delete(&models.IdempotencyKey{}).Error
+}
+
+// DeleteIdempotencyKeysBefore removes the responses stored before the given time
+func (r *IdempotencyRepo) DeleteIdempotencyKeysBefore(before time.Time) (int64, error) {
+	result := r.DB.Where("created_at < ?", before).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"datingApp/models"
+)
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	db := testDB(t)
+	repo := NewIdempotencyRepo(db)
+	user := createTestUser(t, db)
+	newRecord := func(createdAt time.Time) *models.IdempotencyKey {
+		return &models.IdempotencyKey{
+			UserID: user.ID, Key: "key-1", Method: "POST", Path: "/swipe/right",
+			RequestHash: "hash", ResponseBody: []byte{}, CreatedAt: createdAt,
+		}
+	}
+
+	// Two requests race for the same key; only one of them may run
+	now := time.Now()
+	var wg sync.WaitGroup
+	reserved := make([]bool, 2)
+	for i := range reserved {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := repo.ReserveIdempotencyKey(newRecord(now), now.Add(-time.Hour), now.Add(-time.Minute))
+			if err != nil {
+				t.Errorf("ReserveIdempotencyKey: %v", err)
+			}
+			reserved[i] = ok
+		}()
+	}
+	wg.Wait()
+	if reserved[0] == reserved[1] {
+		t.Fatalf("reservations = %v, want exactly one", reserved)
+	}
+
+	stored, err := repo.GetIdempotencyKey(user.ID, "key-1", now.Add(-time.Hour))
+	if err != nil || stored == nil {
+		t.Fatalf("GetIdempotencyKey = %v, %v", stored, err)
+	}
+	if stored.CompletedAt != nil {
+		t.Error("a reserved key is already complete")
+	}
+
+	// A pending reservation older than the abandon cutoff is taken over
+	later := now.Add(2 * time.Minute)
+	ok, err := repo.ReserveIdempotencyKey(newRecord(later), later.Add(-time.Hour), later.Add(-time.Minute))
+	if err != nil || !ok {
+		t.Fatalf("taking over an abandoned key = %v, %v", ok, err)
+	}
+
+	// The abandoned request can no longer complete the key, but the new one can
+	completedAt := later
+	abandoned := newRecord(now)
+	abandoned.StatusCode, abandoned.CompletedAt = 500, &completedAt
+	if err := repo.CompleteIdempotencyKey(abandoned); err != nil {
+		t.Fatal(err)
+	}
+	current := newRecord(later)
+	current.StatusCode, current.ResponseBody, current.CompletedAt = 201, []byte(`{}`), &completedAt
+	if err := repo.CompleteIdempotencyKey(current); err != nil {
+		t.Fatal(err)
+	}
+	stored, err = repo.GetIdempotencyKey(user.ID, "key-1", now.Add(-time.Hour))
+	if err != nil || stored == nil {
+		t.Fatalf("GetIdempotencyKey = %v, %v", stored, err)
+	}
+	if stored.StatusCode != 201 || stored.CompletedAt == nil {
+		t.Errorf("stored response = %d completed at %v, want 201 and complete", stored.StatusCode, stored.CompletedAt)
+	}
+
+	// A completed key is held until it expires
+	ok, err = repo.ReserveIdempotencyKey(newRecord(later.Add(2*time.Minute)), later.Add(-time.Hour), later.Add(time.Minute))
+	if err != nil || ok {
+		t.Errorf("reserving a completed key = %v, %v, want false", ok, err)
+	}
+}
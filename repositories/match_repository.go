@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"datingApp/models"
+)
+
+type MatchRepository interface {
+	GetMatchesForUser(userID uuid.UUID) ([]models.Match, error)
+	GetMatchByID(matchID uuid.UUID) (*models.Match, error)
+	EndMatch(matchID, endedByID uuid.UUID, status string) (bool, error)
+}
+
+type MatchRepo struct {
+	DB *gorm.DB
+}
+
+func NewMatchRepo(db *gorm.DB) *MatchRepo {
+	return &MatchRepo{DB: db}
+}
+
+// GetMatchesForUser returns every active match the user takes part in, newest first
+func (r *MatchRepo) GetMatchesForUser(userID uuid.UUID) ([]models.Match, error) {
+	var matches []models.Match
+	err := r.DB.Where("(user_a_id = ? OR user_b_id = ?) AND status = ?", userID, userID, models.MatchStatusActive).
+		Order("matched_at DESC").
+		Find(&matches).Error
+	return matches, err
+}
+
+// GetMatchByID retrieves a single match, returning nil when it does not exist
+func (r *MatchRepo) GetMatchByID(matchID uuid.UUID) (*models.Match, error) {
+	var match models.Match
+	err := r.DB.Where("id = ?", matchID).First(&match).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+// EndMatch moves an active match to the given final status, recording who ended it.
+// It returns false when the match was no longer active.
+func (r *MatchRepo) EndMatch(matchID, endedByID uuid.UUID, status string) (bool, error) {
+	now := time.Now()
+	result := r.DB.Model(&models.Match{}).
+		Where("id = ? AND status = ?", matchID, models.MatchStatusActive).
+		Updates(map[string]interface{}{"status": status, "ended_by_id": endedByID, "ended_at": now})
+	return result.RowsAffected > 0, result.Error
+}
+
+// orderPair returns the two user IDs in the canonical order used by the matches table
+func orderPair(a, b uuid.UUID) (uuid.UUID, uuid.UUID) {
+	if a.String() < b.String() {
+		return a, b
+	}
+	return b, a
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"datingApp/models"
+)
+
+func TestEndMatchOnlyEndsActiveMatches(t *testing.T) {
+	db := testDB(t)
+	repo := NewMatchRepo(db)
+	user, other := createTestUser(t, db), createTestUser(t, db)
+
+	userA, userB := orderPair(user.ID, other.ID)
+	match := &models.Match{UserAID: userA, UserBID: userB, MatchedAt: time.Now(), Status: models.MatchStatusActive}
+	if err := db.Create(match).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ended, err := repo.EndMatch(match.ID, user.ID, models.MatchStatusUnmatched)
+	if err != nil || !ended {
+		t.Fatalf("EndMatch = %t, %v; want true, nil", ended, err)
+	}
+	// The other user's unmatch loses the race and leaves the first outcome alone
+	ended, err = repo.EndMatch(match.ID, other.ID, models.MatchStatusBlocked)
+	if err != nil || ended {
+		t.Fatalf("second EndMatch = %t, %v; want false, nil", ended, err)
+	}
+
+	stored, err := repo.GetMatchByID(match.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.MatchStatusUnmatched || stored.EndedByID == nil || *stored.EndedByID != user.ID || stored.EndedAt == nil {
+		t.Errorf("stored match = status %q ended by %v at %v, want %q ended by %v", stored.Status, stored.EndedByID,
+			stored.EndedAt, models.MatchStatusUnmatched, user.ID)
+	}
+	matches, err := repo.GetMatchesForUser(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("matches after unmatching = %+v, want none", matches)
+	}
+}
@@ -1,16 +1,27 @@
 package repositories
 
 import (
+	"errors"
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"datingApp/models"
 )
 
+var ErrLifetimeSubscription = errors.New("user already has a lifetime premium subscription")
+
 type PremiumRepository interface {
-	RegisterPremium(userPremium *models.UserPremium) error
+	RegisterPremium(userPremium *models.UserPremium, pkg *models.PremiumPackage) error
 	IsUserPremium(userID uuid.UUID) (bool, error)
 	GetUserPremium(userID uuid.UUID) (*models.UserPremium, error)
+	GetActiveSubscriptions(userID uuid.UUID) ([]models.UserPremium, error)
+	GetLatestSubscription(userID uuid.UUID) (*models.UserPremium, error)
+	ExpireSubscriptions(now time.Time) ([]models.UserPremium, error)
+	ClaimExpiringSubscriptions(now, before time.Time) ([]models.UserPremium, error)
+	GetActiveEntitlements(userID uuid.UUID) ([]models.PackageEntitlement, error)
 	GetPremiumPackages() ([]models.PremiumPackage, error)
 	GetPremiumPackageByID(packageID uuid.UUID) (*models.PremiumPackage, error)
 	CreatePremiumPackage(pkg *models.PremiumPackage) error
@@ -26,31 +37,91 @@ func NewPremiumRepo(db *gorm.DB) *PremiumRepo {
 	return &PremiumRepo{DB: db}
 }
 
-// RegisterPremium registers a new premium subscription for a user
-func (r *PremiumRepo) RegisterPremium(userPremium *models.UserPremium) error {
-	return r.DB.Create(userPremium).Error
+// RegisterPremium registers a new subscription to the package for a user, starting at
+// its purchase date or, when the user already holds subscriptions, stacked after the
+// last one to expire. It sets the subscription's start and expiry dates. Purchases of
+// the same user are serialized so concurrent ones cannot compute the same start date.
+func (r *PremiumRepo) RegisterPremium(userPremium *models.UserPremium, pkg *models.PremiumPackage) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "premium:"+userPremium.UserID.String()).Error; err != nil {
+			return err
+		}
+
+		subscriptions, err := activeSubscriptions(tx, userPremium.UserID, userPremium.PurchaseDate)
+		if err != nil {
+			return err
+		}
+		startDate := userPremium.PurchaseDate
+		for _, sub := range subscriptions {
+			if sub.ExpiresAt == nil {
+				return ErrLifetimeSubscription
+			}
+			if sub.ExpiresAt.After(startDate) {
+				startDate = *sub.ExpiresAt
+			}
+		}
+
+		userPremium.StartDate = startDate
+		if !pkg.IsLifetime() {
+			expiresAt := startDate.AddDate(0, pkg.DurationMonths, 0)
+			userPremium.ExpiresAt = &expiresAt
+		}
+		return tx.Create(userPremium).Error
+	})
 }
 
-// IsUserPremium checks if a user has an active premium subscription
+// activeAt scopes a user_premia query to subscriptions granting premium at the given time
+func activeAt(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ? AND start_date <= ? AND (expires_at IS NULL OR expires_at > ?)",
+			models.SubscriptionStatusActive, now, now)
+	}
+}
+
+// IsUserPremium checks if a user has a subscription covering the current time
 func (r *PremiumRepo) IsUserPremium(userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.UserPremium{}).
+		Scopes(activeAt(time.Now())).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetUserPremium retrieves the subscription currently covering the user, or nil if there is none
+func (r *PremiumRepo) GetUserPremium(userID uuid.UUID) (*models.UserPremium, error) {
 	var userPremium models.UserPremium
-	err := r.DB.Where("user_id = ?", userID).
-		Order("purchase_date DESC").
+	err := r.DB.Scopes(activeAt(time.Now())).
+		Where("user_id = ?", userID).
+		Order("start_date DESC").
 		First(&userPremium).Error
 
 	if err == gorm.ErrRecordNotFound {
-		return false, nil
+		return nil, nil
 	}
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	// Check if the subscription is still valid (you might want to add duration logic here)
-	return true, nil
+	return &userPremium, nil
+}
+
+// GetActiveSubscriptions returns the user's running and stacked (not yet started) subscriptions
+func (r *PremiumRepo) GetActiveSubscriptions(userID uuid.UUID) ([]models.UserPremium, error) {
+	return activeSubscriptions(r.DB, userID, time.Now())
 }
 
-// GetUserPremium retrieves the user's premium subscription details
-func (r *PremiumRepo) GetUserPremium(userID uuid.UUID) (*models.UserPremium, error) {
+func activeSubscriptions(db *gorm.DB, userID uuid.UUID, now time.Time) ([]models.UserPremium, error) {
+	var subscriptions []models.UserPremium
+	err := db.Where("user_id = ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)",
+		userID, models.SubscriptionStatusActive, now).
+		Order("start_date").
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// GetLatestSubscription retrieves the user's most recently purchased subscription regardless of status
+func (r *PremiumRepo) GetLatestSubscription(userID uuid.UUID) (*models.UserPremium, error) {
 	var userPremium models.UserPremium
 	err := r.DB.Where("user_id = ?", userID).
 		Order("purchase_date DESC").
@@ -66,34 +137,119 @@ func (r *PremiumRepo) GetUserPremium(userID uuid.UUID) (*models.UserPremium, err
 	return &userPremium, nil
 }
 
+// ExpireSubscriptions marks every active subscription that ended before now as expired
+// and returns the rows it changed
+func (r *PremiumRepo) ExpireSubscriptions(now time.Time) ([]models.UserPremium, error) {
+	var expired []models.UserPremium
+	err := r.DB.Model(&expired).
+		Clauses(clause.Returning{}).
+		Where("status = ? AND expires_at <= ?", models.SubscriptionStatusActive, now).
+		Update("status", models.SubscriptionStatusExpired).Error
+	return expired, err
+}
+
+// ClaimExpiringSubscriptions marks the active subscriptions ending between now and before
+// as notified and returns them. A subscription followed by a stacked or lifetime one is
+// skipped since premium does not lapse when it ends, and each one is only claimed once.
+func (r *PremiumRepo) ClaimExpiringSubscriptions(now, before time.Time) ([]models.UserPremium, error) {
+	var expiring []models.UserPremium
+	err := r.DB.Model(&expiring).
+		Clauses(clause.Returning{}).
+		Where("status = ? AND expiry_notified_at IS NULL AND expires_at > ? AND expires_at <= ?",
+			models.SubscriptionStatusActive, now, before).
+		Where(`NOT EXISTS (SELECT 1 FROM user_premia later
+			WHERE later.user_id = user_premia.user_id AND later.id <> user_premia.id
+			AND later.status = ? AND later.deleted_at IS NULL
+			AND (later.expires_at IS NULL OR later.expires_at > user_premia.expires_at))`,
+			models.SubscriptionStatusActive).
+		Update("expiry_notified_at", now).Error
+	return expiring, err
+}
+
+// GetActiveEntitlements returns the entitlements of every package the user currently
+// holds, skipping packages that have been deleted
+func (r *PremiumRepo) GetActiveEntitlements(userID uuid.UUID) ([]models.PackageEntitlement, error) {
+	now := time.Now()
+	var entitlements []models.PackageEntitlement
+	err := r.DB.Model(&models.PackageEntitlement{}).
+		Joins("JOIN user_premia ON user_premia.package_id = package_entitlements.package_id").
+		Joins("JOIN premium_packages ON premium_packages.id = package_entitlements.package_id AND premium_packages.deleted_at IS NULL").
+		Where("user_premia.user_id = ? AND user_premia.deleted_at IS NULL", userID).
+		Where("user_premia.status = ? AND user_premia.start_date <= ?", models.SubscriptionStatusActive, now).
+		Where("user_premia.expires_at IS NULL OR user_premia.expires_at > ?", now).
+		Find(&entitlements).Error
+	return entitlements, err
+}
+
 // GetPremiumPackages retrieves all available premium packages
 func (r *PremiumRepo) GetPremiumPackages() ([]models.PremiumPackage, error) {
 	var packages []models.PremiumPackage
-	err := r.DB.Find(&packages).Error
+	err := r.DB.Preload("Entitlements").Find(&packages).Error
 	return packages, err
 }
 
 // GetPremiumPackageByID retrieves a specific premium package by ID
 func (r *PremiumRepo) GetPremiumPackageByID(packageID uuid.UUID) (*models.PremiumPackage, error) {
 	var pkg models.PremiumPackage
-	err := r.DB.Where("package_id = ?", packageID).First(&pkg).Error
+	err := r.DB.Preload("Entitlements").Where("id = ?", packageID).First(&pkg).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, nil
 	}
 	return &pkg, err
 }
 
-// CreatePremiumPackage creates a new premium package
+// CreatePremiumPackage creates a new premium package along with its entitlements
 func (r *PremiumRepo) CreatePremiumPackage(pkg *models.PremiumPackage) error {
 	return r.DB.Create(pkg).Error
 }
 
-// UpdatePremiumPackage updates an existing premium package
+// UpdatePremiumPackage updates an existing premium package and replaces its entitlements.
+// Its subscribers gain or lose the verified badge in the same transaction.
 func (r *PremiumRepo) UpdatePremiumPackage(pkg *models.PremiumPackage) error {
-	return r.DB.Save(pkg).Error
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Entitlements").Save(pkg).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("package_id = ?", pkg.ID).Delete(&models.PackageEntitlement{}).Error; err != nil {
+			return err
+		}
+		if len(pkg.Entitlements) > 0 {
+			for i := range pkg.Entitlements {
+				pkg.Entitlements[i].PackageID = pkg.ID
+			}
+			if err := tx.Create(&pkg.Entitlements).Error; err != nil {
+				return err
+			}
+		}
+		return syncHolderBadges(tx, pkg.ID)
+	})
 }
 
-// DeletePremiumPackage deletes a premium package
+// DeletePremiumPackage deletes a premium package and revokes the verified badge it granted
 func (r *PremiumRepo) DeletePremiumPackage(packageID uuid.UUID) error {
-	return r.DB.Where("package_id = ?", packageID).Delete(&models.PremiumPackage{}).Error
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", packageID).Delete(&models.PremiumPackage{}).Error; err != nil {
+			return err
+		}
+		return syncHolderBadges(tx, packageID)
+	})
+}
+
+// syncHolderBadges recomputes the verified badge of every user subscribed to the
+// package, after its entitlements changed or it was deleted
+func syncHolderBadges(tx *gorm.DB, packageID uuid.UUID) error {
+	return tx.Exec(`UPDATE users SET is_verified = EXISTS (
+		SELECT 1
+		FROM user_premia
+		JOIN package_entitlements ON package_entitlements.package_id = user_premia.package_id
+		JOIN premium_packages ON premium_packages.id = user_premia.package_id AND premium_packages.deleted_at IS NULL
+		WHERE user_premia.user_id = users.id
+		  AND user_premia.deleted_at IS NULL
+		  AND user_premia.status = ?
+		  AND user_premia.start_date <= NOW()
+		  AND (user_premia.expires_at IS NULL OR user_premia.expires_at > NOW())
+		  AND package_entitlements.feature = ?
+	)
+	WHERE id IN (SELECT user_id FROM user_premia WHERE package_id = ? AND deleted_at IS NULL)`,
+		models.SubscriptionStatusActive, models.FeatureVerifiedBadge, packageID).Error
 }
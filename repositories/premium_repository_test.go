@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"datingApp/models"
+)
+
+// createTestPackage inserts a package granting the given features
+func createTestPackage(t *testing.T, db *gorm.DB, durationMonths int, features ...string) *models.PremiumPackage {
+	t.Helper()
+
+	pkg := &models.PremiumPackage{PackageName: "Test package", Price: decimal.NewFromInt(10), DurationMonths: durationMonths}
+	for _, feature := range features {
+		pkg.Entitlements = append(pkg.Entitlements, models.PackageEntitlement{Feature: feature})
+	}
+	if err := NewPremiumRepo(db).CreatePremiumPackage(pkg); err != nil {
+		t.Fatalf("failed to create package: %v", err)
+	}
+	return pkg
+}
+
+// subscribe buys the package for the user and turns on the badge, as the service does
+func subscribe(t *testing.T, db *gorm.DB, user *models.User, pkg *models.PremiumPackage) *models.UserPremium {
+	t.Helper()
+
+	sub := &models.UserPremium{
+		UserID:       user.ID,
+		PackageID:    pkg.ID,
+		PurchaseDate: time.Now(),
+		Status:       models.SubscriptionStatusActive,
+	}
+	if err := NewPremiumRepo(db).RegisterPremium(sub, pkg); err != nil {
+		t.Fatalf("RegisterPremium: %v", err)
+	}
+	return sub
+}
+
+func TestPackageChangeResyncsVerifiedBadge(t *testing.T) {
+	tests := []struct {
+		name string
+		// change edits the badge package after users subscribed to it
+		change       func(repo *PremiumRepo, pkg *models.PremiumPackage) error
+		wantVerified bool
+	}{
+		{"badge kept", func(repo *PremiumRepo, pkg *models.PremiumPackage) error {
+			pkg.Description = "Renamed"
+			return repo.UpdatePremiumPackage(pkg)
+		}, true},
+		{"badge removed", func(repo *PremiumRepo, pkg *models.PremiumPackage) error {
+			pkg.Entitlements = []models.PackageEntitlement{{Feature: models.FeatureUnlimitedSwipes}}
+			return repo.UpdatePremiumPackage(pkg)
+		}, false},
+		{"package deleted", func(repo *PremiumRepo, pkg *models.PremiumPackage) error {
+			return repo.DeletePremiumPackage(pkg.ID)
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			repo, users := NewPremiumRepo(db), NewUserRepo(db)
+			badgePackage := createTestPackage(t, db, 0, models.FeatureVerifiedBadge)
+			otherBadgePackage := createTestPackage(t, db, 0, models.FeatureVerifiedBadge)
+
+			holder, otherHolder := createTestUser(t, db), createTestUser(t, db)
+			subscribe(t, db, holder, badgePackage)
+			subscribe(t, db, otherHolder, otherBadgePackage)
+			for _, user := range []*models.User{holder, otherHolder} {
+				if err := users.SetVerified(user.ID, true); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pkg, err := repo.GetPremiumPackageByID(badgePackage.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.change(repo, pkg); err != nil {
+				t.Fatalf("changing the package: %v", err)
+			}
+
+			got, err := users.GetUserByID(holder.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.IsVerified != tt.wantVerified {
+				t.Errorf("holder verified = %t, want %t", got.IsVerified, tt.wantVerified)
+			}
+			got, err = users.GetUserByID(otherHolder.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.IsVerified {
+				t.Error("a holder of another package lost the badge")
+			}
+		})
+	}
+}
+
+func TestActiveEntitlementsFollowSubscriptionLifetime(t *testing.T) {
+	db := testDB(t)
+	repo := NewPremiumRepo(db)
+	user := createTestUser(t, db)
+	pkg := createTestPackage(t, db, 1, models.FeatureVerifiedBadge)
+	sub := subscribe(t, db, user, pkg)
+
+	entitlements, err := repo.GetActiveEntitlements(user.ID)
+	if err != nil {
+		t.Fatalf("GetActiveEntitlements: %v", err)
+	}
+	if len(entitlements) != 1 || entitlements[0].Feature != models.FeatureVerifiedBadge {
+		t.Errorf("entitlements after purchase = %+v, want the verified badge", entitlements)
+	}
+
+	expired, err := repo.ExpireSubscriptions(sub.ExpiresAt.Add(time.Second))
+	if err != nil {
+		t.Fatalf("ExpireSubscriptions: %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != sub.ID {
+		t.Fatalf("expired = %+v, want the subscription", expired)
+	}
+	entitlements, err = repo.GetActiveEntitlements(user.ID)
+	if err != nil {
+		t.Fatalf("GetActiveEntitlements: %v", err)
+	}
+	if len(entitlements) != 0 {
+		t.Errorf("entitlements after expiry = %+v, want none", entitlements)
+	}
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"datingApp/models"
+)
+
+type ProfileRepository interface {
+	GetProfileByUserID(userID uuid.UUID) (*models.Profile, error)
+	GetProfilesByUserIDs(userIDs []uuid.UUID) ([]models.Profile, error)
+	UpdateProfile(userID uuid.UUID, userUpdates, profileUpdates map[string]interface{}) error
+}
+
+type ProfileRepo struct {
+	DB *gorm.DB
+}
+
+func NewProfileRepo(db *gorm.DB) *ProfileRepo {
+	return &ProfileRepo{DB: db}
+}
+
+// GetProfileByUserID retrieves a user's profile together with the user, returning nil when either is missing
+func (r *ProfileRepo) GetProfileByUserID(userID uuid.UUID) (*models.Profile, error) {
+	var profile models.Profile
+	err := r.DB.Preload("User").Where("user_id = ?", userID).First(&profile).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The user itself may have been deleted
+	if profile.User.ID == uuid.Nil {
+		return nil, nil
+	}
+	return &profile, nil
+}
+
+// GetProfilesByUserIDs retrieves the profiles of the given users together with the
+// users, skipping users that are missing or deleted
+func (r *ProfileRepo) GetProfilesByUserIDs(userIDs []uuid.UUID) ([]models.Profile, error) {
+	var profiles []models.Profile
+	err := r.DB.InnerJoins("User").Where("profiles.user_id IN ?", userIDs).Find(&profiles).Error
+	return profiles, err
+}
+
+// UpdateProfile applies partial updates to the user and their profile in a single transaction
+func (r *ProfileRepo) UpdateProfile(userID uuid.UUID, userUpdates, profileUpdates map[string]interface{}) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if len(userUpdates) > 0 {
+			err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(userUpdates).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(profileUpdates) > 0 {
+			err := tx.Model(&models.Profile{}).Where("user_id = ?", userID).Updates(profileUpdates).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"datingApp/models"
+)
+
+var (
+	ErrReportExists     = errors.New("you already reported this user")
+	ErrReportNotClaimed = errors.New("report is not claimed by you")
+)
+
+type ReportRepository interface {
+	CreateReport(report *models.Report, autoHideThreshold int) (bool, error)
+	GetReports(status string, after *models.Cursor, limit int) ([]models.Report, error)
+	GetReportByID(reportID uuid.UUID) (*models.Report, error)
+	ClaimReport(reportID, moderatorID uuid.UUID) (bool, error)
+	ResolveReport(decision *models.ModerationDecision) error
+	GetDecisionsForUser(userID uuid.UUID) ([]models.ModerationDecision, error)
+}
+
+type ReportRepo struct {
+	DB *gorm.DB
+}
+
+func NewReportRepo(db *gorm.DB) *ReportRepo {
+	return &ReportRepo{DB: db}
+}
+
+// openReportStatuses are the statuses of reports still awaiting a decision
+var openReportStatuses = []string{models.ReportStatusPending, models.ReportStatusInReview}
+
+// CreateReport files the report and, once autoHideThreshold distinct users have open
+// reports about the reported user, hides their profile until a moderator decides.
+// A threshold of 0 disables hiding. It returns whether this report hid the profile.
+func (r *ReportRepo) CreateReport(report *models.Report, autoHideThreshold int) (bool, error) {
+	hidden := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialize the reports about one user so concurrent ones are counted together
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "reports:"+report.ReportedID.String()).Error; err != nil {
+			return err
+		}
+
+		var existing int64
+		err := tx.Model(&models.Report{}).
+			Where("reporter_id = ? AND reported_id = ? AND status IN ?", report.ReporterID, report.ReportedID, openReportStatuses).
+			Count(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrReportExists
+		}
+
+		if err := tx.Create(report).Error; err != nil {
+			return err
+		}
+		if autoHideThreshold <= 0 {
+			return nil
+		}
+
+		var reporters int64
+		err = tx.Model(&models.Report{}).
+			Where("reported_id = ? AND status IN ?", report.ReportedID, openReportStatuses).
+			Distinct("reporter_id").
+			Count(&reporters).Error
+		if err != nil || reporters < int64(autoHideThreshold) {
+			return err
+		}
+
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND hidden_at IS NULL", report.ReportedID).
+			Updates(map[string]interface{}{"hidden_at": time.Now(), "hidden_reason": models.HiddenReasonReports})
+		hidden = result.RowsAffected > 0
+		return result.Error
+	})
+	return hidden, err
+}
+
+// GetReports returns up to limit reports with the given status, oldest first by
+// (created_at, id) and starting after the cursor
+func (r *ReportRepo) GetReports(status string, after *models.Cursor, limit int) ([]models.Report, error) {
+	var reports []models.Report
+	query := r.DB.Where("status = ?", status)
+	if after != nil {
+		query = query.Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+	err := query.Order("created_at, id").Limit(limit).Find(&reports).Error
+	return reports, err
+}
+
+// GetReportByID retrieves a single report, returning nil when it does not exist
+func (r *ReportRepo) GetReportByID(reportID uuid.UUID) (*models.Report, error) {
+	var report models.Report
+	err := r.DB.Where("id = ?", reportID).First(&report).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ClaimReport assigns a pending report to the moderator. It returns false when the
+// report was claimed by another moderator or already resolved; claiming it again is
+// a no-op.
+func (r *ReportRepo) ClaimReport(reportID, moderatorID uuid.UUID) (bool, error) {
+	result := r.DB.Model(&models.Report{}).
+		Where("id = ? AND (status = ? OR (status = ? AND claimed_by_id = ?))",
+			reportID, models.ReportStatusPending, models.ReportStatusInReview, moderatorID).
+		Updates(map[string]interface{}{
+			"status":        models.ReportStatusInReview,
+			"claimed_by_id": moderatorID,
+			"claimed_at":    gorm.Expr("COALESCE(claimed_at, ?)", time.Now()),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ResolveReport closes a report claimed by the decision's moderator, records the
+// decision and applies it to the reported user's profile. Hiding the profile overrides
+// an automatic hide; any other action lifts an automatic hide once no open report is left.
+func (r *ReportRepo) ResolveReport(decision *models.ModerationDecision) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "reports:"+decision.ReportedID.String()).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Report{}).
+			Where("id = ? AND status = ? AND claimed_by_id = ?", decision.ReportID, models.ReportStatusInReview, decision.ModeratorID).
+			Updates(map[string]interface{}{"status": models.ReportStatusResolved, "resolved_at": decision.CreatedAt})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrReportNotClaimed
+		}
+
+		if err := tx.Create(decision).Error; err != nil {
+			return err
+		}
+
+		if decision.Action == models.ModerationActionHideProfile {
+			return tx.Model(&models.User{}).
+				Where("id = ?", decision.ReportedID).
+				Updates(map[string]interface{}{
+					"hidden_at":     gorm.Expr("COALESCE(hidden_at, ?)", decision.CreatedAt),
+					"hidden_reason": models.HiddenReasonModerator,
+				}).Error
+		}
+		// Any other outcome lifts the automatic hide once the last open report is closed
+		return tx.Model(&models.User{}).
+			Where("id = ? AND hidden_reason = ?", decision.ReportedID, models.HiddenReasonReports).
+			Where("NOT EXISTS (SELECT 1 FROM reports WHERE reports.reported_id = users.id AND reports.status IN ?)",
+				openReportStatuses).
+			Updates(map[string]interface{}{"hidden_at": nil, "hidden_reason": ""}).Error
+	})
+}
+
+// GetDecisionsForUser returns the moderation decisions about the user, newest first
+func (r *ReportRepo) GetDecisionsForUser(userID uuid.UUID) ([]models.ModerationDecision, error) {
+	var decisions []models.ModerationDecision
+	err := r.DB.Where("reported_id = ?", userID).Order("created_at DESC").Find(&decisions).Error
+	return decisions, err
+}
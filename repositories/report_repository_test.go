@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"datingApp/models"
+)
+
+func TestResolveReportLiftsAutomaticHide(t *testing.T) {
+	tests := []struct {
+		action     string
+		wantHidden bool
+		wantReason string
+	}{
+		{models.ModerationActionDismiss, false, ""},
+		{models.ModerationActionWarn, false, ""},
+		{models.ModerationActionHideProfile, true, models.HiddenReasonModerator},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			db := testDB(t)
+			repo := NewReportRepo(db)
+			reporter, reported, moderator := createTestUser(t, db), createTestUser(t, db), createTestUser(t, db)
+
+			report := &models.Report{ReporterID: reporter.ID, ReportedID: reported.ID, Reason: models.ReportReasonSpam}
+			hidden, err := repo.CreateReport(report, 1)
+			if err != nil {
+				t.Fatalf("CreateReport: %v", err)
+			}
+			if !hidden {
+				t.Fatal("the report did not hide the profile")
+			}
+
+			claimed, err := repo.ClaimReport(report.ID, moderator.ID)
+			if err != nil || !claimed {
+				t.Fatalf("ClaimReport = %v, %v", claimed, err)
+			}
+			err = repo.ResolveReport(&models.ModerationDecision{
+				ReportID:    report.ID,
+				ModeratorID: moderator.ID,
+				ReportedID:  reported.ID,
+				Action:      tt.action,
+				CreatedAt:   time.Now(),
+			})
+			if err != nil {
+				t.Fatalf("ResolveReport: %v", err)
+			}
+
+			user, err := NewUserRepo(db).GetUserByID(reported.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (user.HiddenAt != nil) != tt.wantHidden || user.HiddenReason != tt.wantReason {
+				t.Errorf("hidden at %v for %q, want hidden %t for %q", user.HiddenAt, user.HiddenReason, tt.wantHidden, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestResolveReportKeepsHideWhileReportsAreOpen(t *testing.T) {
+	db := testDB(t)
+	repo := NewReportRepo(db)
+	reported, moderator := createTestUser(t, db), createTestUser(t, db)
+
+	var reports []*models.Report
+	for i := 0; i < 2; i++ {
+		report := &models.Report{ReporterID: createTestUser(t, db).ID, ReportedID: reported.ID, Reason: models.ReportReasonSpam}
+		if _, err := repo.CreateReport(report, 2); err != nil {
+			t.Fatalf("CreateReport: %v", err)
+		}
+		reports = append(reports, report)
+	}
+
+	if claimed, err := repo.ClaimReport(reports[0].ID, moderator.ID); err != nil || !claimed {
+		t.Fatalf("ClaimReport = %v, %v", claimed, err)
+	}
+	err := repo.ResolveReport(&models.ModerationDecision{
+		ReportID:    reports[0].ID,
+		ModeratorID: moderator.ID,
+		ReportedID:  reported.ID,
+		Action:      models.ModerationActionWarn,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("ResolveReport: %v", err)
+	}
+
+	user, err := NewUserRepo(db).GetUserByID(reported.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.HiddenAt == nil || user.HiddenReason != models.HiddenReasonReports {
+		t.Errorf("profile unhidden with a report still open: hidden at %v for %q", user.HiddenAt, user.HiddenReason)
+	}
+}
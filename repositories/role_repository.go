@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"datingApp/models"
+)
+
+// ErrLastRoleHolder is returned when removing a role would leave nobody holding it
+var ErrLastRoleHolder = errors.New("cannot remove the last holder of the role")
+
+type RoleRepository interface {
+	GetRoles() ([]models.Role, error)
+	GetRoleByName(name string) (*models.Role, error)
+	GetUserRoleNames(userID uuid.UUID) ([]string, error)
+	UserHasPermission(userID uuid.UUID, permission string) (bool, error)
+	AssignRole(userRole *models.UserRole) error
+	RemoveRole(userID, roleID uuid.UUID) error
+	RemoveRoleKeepingLast(userID, roleID uuid.UUID) error
+	CountUsersWithRole(roleID uuid.UUID) (int64, error)
+}
+
+type RoleRepo struct {
+	DB *gorm.DB
+}
+
+func NewRoleRepo(db *gorm.DB) *RoleRepo {
+	return &RoleRepo{DB: db}
+}
+
+// GetRoles retrieves every role with its permissions
+func (r *RoleRepo) GetRoles() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.DB.Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
+// GetRoleByName retrieves a role, returning nil when it does not exist
+func (r *RoleRepo) GetRoleByName(name string) (*models.Role, error) {
+	var role models.Role
+	err := r.DB.Where("name = ?", name).First(&role).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetUserRoleNames returns the names of the roles granted to the user
+func (r *RoleRepo) GetUserRoleNames(userID uuid.UUID) ([]string, error) {
+	names := []string{}
+	err := r.DB.Model(&models.Role{}).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Pluck("roles.name", &names).Error
+	return names, err
+}
+
+// UserHasPermission checks whether any of the user's roles grants the permission
+func (r *RoleRepo) UserHasPermission(userID uuid.UUID, permission string) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.UserRole{}).
+		Joins("JOIN role_permissions ON role_permissions.role_id = user_roles.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("user_roles.user_id = ? AND permissions.name = ?", userID, permission).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// AssignRole grants a role to a user; granting a role twice is a no-op
+func (r *RoleRepo) AssignRole(userRole *models.UserRole) error {
+	return r.DB.Omit("User", "Role").Clauses(clause.OnConflict{DoNothing: true}).Create(userRole).Error
+}
+
+// RemoveRole revokes a role from a user
+func (r *RoleRepo) RemoveRole(userID, roleID uuid.UUID) error {
+	return r.DB.Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&models.UserRole{}).Error
+}
+
+// RemoveRoleKeepingLast revokes a role from a user unless they are its last holder.
+// Removals of the same role are serialized so two holders cannot both drop it at once.
+func (r *RoleRepo) RemoveRoleKeepingLast(userID, roleID uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "role:"+roleID.String()).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.UserRole{}).Where("role_id = ?", roleID).Count(&count).Error; err != nil {
+			return err
+		}
+		result := tx.Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&models.UserRole{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 && count <= 1 {
+			return ErrLastRoleHolder
+		}
+		return nil
+	})
+}
+
+// CountUsersWithRole returns how many users hold the role
+func (r *RoleRepo) CountUsersWithRole(roleID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.DB.Model(&models.UserRole{}).Where("role_id = ?", roleID).Count(&count).Error
+	return count, err
+}
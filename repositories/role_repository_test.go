@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"datingApp/models"
+)
+
+func TestRemoveRoleKeepingLast(t *testing.T) {
+	db := testDB(t)
+	repo := NewRoleRepo(db)
+	role, err := repo.GetRoleByName(models.RoleSuperAdmin)
+	if err != nil || role == nil {
+		t.Fatalf("GetRoleByName = %v, %v", role, err)
+	}
+	alice, bob, carol := createTestUser(t, db), createTestUser(t, db), createTestUser(t, db)
+	for _, user := range []*models.User{alice, bob} {
+		if err := repo.AssignRole(&models.UserRole{UserID: user.ID, RoleID: role.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := repo.RemoveRoleKeepingLast(carol.ID, role.ID); err != nil {
+		t.Errorf("removing a role the user does not hold: %v", err)
+	}
+
+	// Both admins try to drop the role at once; exactly one of them must keep it
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, user := range []*models.User{alice, bob} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = repo.RemoveRoleKeepingLast(user.ID, role.ID)
+		}()
+	}
+	wg.Wait()
+
+	refused := 0
+	for _, err := range errs {
+		if errors.Is(err, ErrLastRoleHolder) {
+			refused++
+		} else if err != nil {
+			t.Errorf("RemoveRoleKeepingLast: %v", err)
+		}
+	}
+	if refused != 1 {
+		t.Errorf("%d removals were refused, want 1", refused)
+	}
+	count, err := repo.CountUsersWithRole(role.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("%d users hold the role, want 1", count)
+	}
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"datingApp/models"
+)
+
+type SessionRepository interface {
+	CreateSession(session *models.Session, token *models.RefreshToken) error
+	GetSessionByID(sessionID uuid.UUID) (*models.Session, error)
+	RevokeSession(sessionID uuid.UUID) error
+	RevokeUserSessions(userID uuid.UUID) error
+	GetRefreshToken(tokenHash string) (*models.RefreshToken, error)
+	MarkRefreshTokenUsed(tokenID uuid.UUID) (bool, error)
+	CreateRefreshToken(token *models.RefreshToken) error
+}
+
+type SessionRepo struct {
+	DB *gorm.DB
+}
+
+func NewSessionRepo(db *gorm.DB) *SessionRepo {
+	return &SessionRepo{DB: db}
+}
+
+// CreateSession starts a new session together with its first refresh token
+func (r *SessionRepo) CreateSession(session *models.Session, token *models.RefreshToken) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User").Create(session).Error; err != nil {
+			return err
+		}
+		token.SessionID = session.ID
+		return tx.Omit("Session").Create(token).Error
+	})
+}
+
+// GetSessionByID retrieves a session, returning nil when it does not exist
+func (r *SessionRepo) GetSessionByID(sessionID uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	err := r.DB.Where("id = ?", sessionID).First(&session).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RevokeSession ends the session, invalidating every token issued for it
+func (r *SessionRepo) RevokeSession(sessionID uuid.UUID) error {
+	return r.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserSessions ends every active session of the user
+func (r *SessionRepo) RevokeUserSessions(userID uuid.UUID) error {
+	return r.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// GetRefreshToken looks a refresh token up by its hash along with its session
+func (r *SessionRepo) GetRefreshToken(tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.DB.Preload("Session").Where("token_hash = ?", tokenHash).First(&token).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed consumes the token. It returns false when the token had
+// already been used, which lets concurrent refreshes detect each other.
+func (r *SessionRepo) MarkRefreshTokenUsed(tokenID uuid.UUID) (bool, error) {
+	result := r.DB.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL", tokenID).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// CreateRefreshToken stores a rotated refresh token for an existing session
+func (r *SessionRepo) CreateRefreshToken(token *models.RefreshToken) error {
+	return r.DB.Omit("Session").Create(token).Error
+}
@@ -1,16 +1,49 @@
 package repositories
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"datingApp/models"
+)
+
+var (
+	// ErrSwipeExists is returned when the user already swiped on the target
+	ErrSwipeExists = errors.New("you already swiped on this user")
+	// ErrSwipeMatched is returned when turning a like into a pass on an actively matched user
+	ErrSwipeMatched = errors.New("cannot pass on a user you are matched with")
+	// ErrQuotaExceeded is returned when the swipe would go over the daily quota
+	ErrQuotaExceeded = errors.New("daily swipe quota exceeded")
+	// ErrSuperLikesExhausted is returned when the user has no super likes left today
+	ErrSuperLikesExhausted = errors.New("no super likes left today")
+	// ErrNothingToUndo is returned when the user has no swipe recent enough to undo
+	ErrNothingToUndo = errors.New("no recent swipe to undo")
+	// ErrUndoLimitReached is returned when the user used all of today's undos
+	ErrUndoLimitReached = errors.New("daily rewind limit reached")
 )
 
+// QuotaCharge is the daily quota slot a new swipe consumes
+type QuotaCharge struct {
+	// Day is the quota day; only its date is stored
+	Day time.Time
+	// Limit is the number of swipes allowed on that day, nil when unlimited
+	Limit *int
+	// Super charges the separate super like allowance instead of the swipe quota
+	Super bool
+}
+
 type SwipeRepository interface {
-	RecordSwipe(userID, targetUserID uuid.UUID, isLike bool) error
-	GetDailySwipeCount(userID uuid.UUID) (int, error)
-	GetSwipedUserIDs(userID uuid.UUID, date time.Time) ([]uuid.UUID, error)
+	GetSwipe(userID, targetUserID uuid.UUID) (*models.Swipe, error)
+	RecordSwipe(userID, targetUserID uuid.UUID, swipeType string, passRecycleBefore *time.Time, charge QuotaCharge) (*models.Match, error)
+	ChangeSwipe(userID, targetUserID uuid.UUID, swipeType string) (*models.Match, error)
+	GetQuota(userID uuid.UUID, day time.Time) (*models.SwipeQuota, error)
+	UndoLastSwipe(userID uuid.UUID, swipedAfter, today time.Time, dailyLimit int) (*models.Swipe, *models.Match, error)
+	CountLikesReceived(userID uuid.UUID) (int, error)
+	GetLikesReceived(userID uuid.UUID, after *models.Cursor, limit int) ([]models.Swipe, error)
 }
 
 type SwipeRepo struct {
@@ -21,30 +54,376 @@ func NewSwipeRepo(db *gorm.DB) *SwipeRepo {
 	return &SwipeRepo{DB: db}
 }
 
-// RecordSwipe stores the swipe action in the database
-func (r *SwipeRepo) RecordSwipe(userID, profileID uuid.UUID, isLike bool) error {
-	return r.DB.Exec("INSERT INTO swipes (user_id, profile_id, is_like, created_at) VALUES (?, ?, ?, NOW())",
-		userID, profileID, isLike).Error
-}
-
-// GetDailySwipeCount returns the number of swipes a user has made today
-func (r *SwipeRepo) GetDailySwipeCount(userID uuid.UUID) (int, error) {
-	var count int
-	err := r.DB.Raw(`
-		SELECT COUNT(*) 
-		FROM swipes 
-		WHERE user_id = ? AND DATE(created_at) = CURRENT_DATE
-	`, userID).Scan(&count).Error
-	return count, err
-}
-
-// GetSwipedUserIDs returns the IDs of users the given user has swiped on today
-func (r *SwipeRepo) GetSwipedUserIDs(userID uuid.UUID, date time.Time) ([]uuid.UUID, error) {
-	var swipedIDs []uuid.UUID
-	err := r.DB.Raw(`
-		SELECT target_user_id 
-		FROM swipes 
-		WHERE user_id = ? AND DATE(created_at) = ?
-	`, userID, date.Format("2006-01-02")).Scan(&swipedIDs).Error
-	return swipedIDs, err
+// GetSwipe returns the user's swipe on the target, or nil when there is none
+func (r *SwipeRepo) GetSwipe(userID, targetUserID uuid.UUID) (*models.Swipe, error) {
+	var swipe models.Swipe
+	err := r.DB.Where("user_id = ? AND target_user_id = ?", userID, targetUserID).First(&swipe).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &swipe, nil
+}
+
+// RecordSwipe stores the swipe action in the database. When the swipe is a like or
+// a super like and the other user already liked back, a match is created in the same
+// transaction and returned; otherwise the returned match is nil. A pass made before
+// passRecycleBefore is replaced by the new swipe, since discovery shows the target
+// again; with a nil time passes are never replaced. It fails with ErrSwipeExists when
+// the user already swiped on the target and with ErrQuotaExceeded when the charge
+// would go over the day's limit, in which case nothing is stored.
+func (r *SwipeRepo) RecordSwipe(userID, targetUserID uuid.UUID, swipeType string, passRecycleBefore *time.Time, charge QuotaCharge) (*models.Match, error) {
+	var match *models.Match
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		swipe := &models.Swipe{
+			UserID:       userID,
+			TargetUserID: targetUserID,
+			SwipeType:    swipeType,
+			SwipeDate:    time.Now(),
+		}
+		if swipe.IsLike() {
+			if err := lockPair(tx, userID, targetUserID); err != nil {
+				return err
+			}
+		}
+
+		conflict := clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "target_user_id"}},
+			DoNothing: true,
+		}
+		if passRecycleBefore != nil {
+			// Overwrite a recycled pass in place; any other existing swipe is kept
+			conflict = clause.OnConflict{
+				Columns:   conflict.Columns,
+				DoUpdates: clause.AssignmentColumns([]string{"swipe_type", "swipe_date", "created_at", "updated_at"}),
+				Where: clause.Where{Exprs: []clause.Expression{
+					clause.Expr{SQL: "swipes.swipe_type = ? AND swipes.swipe_date < ?", Vars: []interface{}{models.SwipeTypePass, *passRecycleBefore}},
+				}},
+			}
+		}
+		result := tx.Omit("User", "TargetUser").Clauses(conflict).Create(swipe)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSwipeExists
+		}
+
+		if err := consumeQuota(tx, userID, charge); err != nil {
+			return err
+		}
+		if !swipe.IsLike() {
+			return nil
+		}
+
+		m, err := matchIfMutual(tx, userID, targetUserID)
+		match = m
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// ChangeSwipe turns the user's existing swipe on the target into a like or a pass.
+// A pass that becomes a like creates the match when the target already liked
+// back. A like cannot become a pass while the two users are matched, and a super
+// like already counts as a like. Changing a swipe does not move its swipe date, so
+// it is not counted against today's quota.
+func (r *SwipeRepo) ChangeSwipe(userID, targetUserID uuid.UUID, swipeType string) (*models.Match, error) {
+	var match *models.Match
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPair(tx, userID, targetUserID); err != nil {
+			return err
+		}
+
+		var swipe models.Swipe
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND target_user_id = ?", userID, targetUserID).
+			First(&swipe).Error
+		if err != nil || swipe.SwipeType == swipeType {
+			return err
+		}
+		if swipeType == models.SwipeTypeLike && swipe.IsLike() {
+			return nil
+		}
+
+		if swipeType == models.SwipeTypePass {
+			userA, userB := orderPair(userID, targetUserID)
+			var matched int64
+			err := tx.Model(&models.Match{}).
+				Where("user_a_id = ? AND user_b_id = ? AND status = ?", userA, userB, models.MatchStatusActive).
+				Count(&matched).Error
+			if err != nil {
+				return err
+			}
+			if matched > 0 {
+				return ErrSwipeMatched
+			}
+		}
+
+		err = tx.Model(&swipe).Update("swipe_type", swipeType).Error
+		if err != nil || !swipe.IsLike() {
+			return err
+		}
+
+		m, err := matchIfMutual(tx, userID, targetUserID)
+		match = m
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// GetQuota returns the swipes charged to the user's quota on the given day. A day
+// without swipes returns a zero counter.
+func (r *SwipeRepo) GetQuota(userID uuid.UUID, day time.Time) (*models.SwipeQuota, error) {
+	quota := models.SwipeQuota{UserID: userID, Day: quotaDay(day)}
+	err := r.DB.Where("user_id = ? AND day = ?", userID, quotaDay(day)).First(&quota).Error
+	if err == gorm.ErrRecordNotFound {
+		return &quota, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// UndoLastSwipe deletes the user's most recent swipe if it was made after swipedAfter,
+// ending the match it created and refunding the quota it consumed. The ended match is
+// returned, or nil when there was none. Undos are logged on today's date and at most
+// dailyLimit are allowed per day. today must be in the user's time zone, which is also
+// used to find the quota day of the swipe.
+func (r *SwipeRepo) UndoLastSwipe(userID uuid.UUID, swipedAfter, today time.Time, dailyLimit int) (*models.Swipe, *models.Match, error) {
+	var undone *models.Swipe
+	var ended *models.Match
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialise the user's undos so the daily limit holds and two undos
+		// cannot pick the same swipe
+		err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "undo:"+userID.String()).Error
+		if err != nil {
+			return err
+		}
+
+		var used int64
+		err = tx.Model(&models.SwipeUndo{}).
+			Where("user_id = ? AND day = ?", userID, quotaDay(today)).
+			Count(&used).Error
+		if err != nil {
+			return err
+		}
+		if int(used) >= dailyLimit {
+			return ErrUndoLimitReached
+		}
+
+		var swipe models.Swipe
+		err = tx.Where("user_id = ?", userID).
+			Order("swipe_date DESC, id DESC").
+			First(&swipe).Error
+		if err == gorm.ErrRecordNotFound || (err == nil && !swipe.SwipeDate.After(swipedAfter)) {
+			return ErrNothingToUndo
+		}
+		if err != nil {
+			return err
+		}
+
+		// Re-read the swipe under the pair lock, a change to it may have been waiting for the lock
+		if err := lockPair(tx, userID, swipe.TargetUserID); err != nil {
+			return err
+		}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", swipe.ID).First(&swipe).Error
+		if err == gorm.ErrRecordNotFound {
+			return ErrNothingToUndo
+		}
+		if err != nil {
+			return err
+		}
+
+		if swipe.IsLike() {
+			// A match needs both likes, so it cannot outlive this one. It ends as if the
+			// user unmatched, keeping the conversation; a match that already ended is
+			// kept as the record of who ended it.
+			userA, userB := orderPair(userID, swipe.TargetUserID)
+			var match models.Match
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("user_a_id = ? AND user_b_id = ? AND status = ?", userA, userB, models.MatchStatusActive).
+				First(&match).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+			if err == nil {
+				now := time.Now()
+				err := tx.Model(&match).Updates(map[string]interface{}{
+					"status": models.MatchStatusUnmatched, "ended_by_id": userID, "ended_at": now,
+				}).Error
+				if err != nil {
+					return err
+				}
+				match.Status, match.EndedByID, match.EndedAt = models.MatchStatusUnmatched, &userID, &now
+				ended = &match
+			}
+		}
+
+		if err := tx.Delete(&swipe).Error; err != nil {
+			return err
+		}
+
+		// Super likes were charged to their own allowance
+		column := "used"
+		if swipe.SwipeType == models.SwipeTypeSuperLike {
+			column = "super_used"
+		}
+		err = tx.Model(&models.SwipeQuota{}).
+			Where("user_id = ? AND day = ? AND "+column+" > 0", userID, quotaDay(swipe.SwipeDate.In(today.Location()))).
+			Update(column, gorm.Expr(column+" - 1")).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Create(&models.SwipeUndo{
+			UserID:       userID,
+			TargetUserID: swipe.TargetUserID,
+			SwipeType:    swipe.SwipeType,
+			SwipedAt:     swipe.SwipeDate,
+			Day:          quotaDay(today),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		undone = &swipe
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return undone, ended, nil
+}
+
+// CountLikesReceived returns how many users liked the user without being swiped on in return
+func (r *SwipeRepo) CountLikesReceived(userID uuid.UUID) (int, error) {
+	var count int64
+	err := r.likesReceived(userID).Count(&count).Error
+	return int(count), err
+}
+
+// GetLikesReceived returns up to limit unanswered likes the user received, newest first
+// by (created_at, id) and starting after the cursor
+func (r *SwipeRepo) GetLikesReceived(userID uuid.UUID, after *models.Cursor, limit int) ([]models.Swipe, error) {
+	var swipes []models.Swipe
+	query := r.likesReceived(userID)
+	if after != nil {
+		query = query.Where("(swipes.created_at, swipes.id) < (?, ?)", after.CreatedAt, after.ID)
+	}
+	err := query.Order("swipes.created_at DESC, swipes.id DESC").Limit(limit).Find(&swipes).Error
+	return swipes, err
+}
+
+// likesReceived selects the likes and super likes sent to the user by existing, visible
+// users the user has not swiped on yet and is not blocked with
+func (r *SwipeRepo) likesReceived(userID uuid.UUID) *gorm.DB {
+	return r.DB.Model(&models.Swipe{}).
+		Joins("JOIN users ON users.id = swipes.user_id AND users.deleted_at IS NULL AND users.hidden_at IS NULL").
+		Where("swipes.target_user_id = ? AND swipes.swipe_type <> ?", userID, models.SwipeTypePass).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM swipes AS answers
+			WHERE answers.user_id = ? AND answers.target_user_id = swipes.user_id
+		)`, userID).
+		Where(notBlockedWith("swipes.user_id"), userID, userID)
+}
+
+// consumeQuota takes one swipe, or one super like, from the user's counter for the
+// charged day. The increment only applies while the counter is below the limit, so
+// concurrent swipes cannot go over it: the row lock taken by the upsert makes them
+// wait for each other and re-check the limit.
+func consumeQuota(tx *gorm.DB, userID uuid.UUID, charge QuotaCharge) error {
+	quota := &models.SwipeQuota{UserID: userID, Day: quotaDay(charge.Day)}
+	column, exceeded := "used", ErrQuotaExceeded
+	if charge.Super {
+		quota.SuperUsed = 1
+		column, exceeded = "super_used", ErrSuperLikesExhausted
+	} else {
+		quota.Used = 1
+	}
+
+	if charge.Limit != nil && *charge.Limit <= 0 {
+		return exceeded
+	}
+
+	onConflict := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{column: gorm.Expr("swipe_quotas." + column + " + 1")}),
+	}
+	if charge.Limit != nil {
+		onConflict.Where = clause.Where{Exprs: []clause.Expression{
+			clause.Lt{Column: clause.Column{Table: "swipe_quotas", Name: column}, Value: *charge.Limit},
+		}}
+	}
+
+	result := tx.Clauses(onConflict).Create(quota)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return exceeded
+	}
+	return nil
+}
+
+// quotaDay keeps only the calendar date of t, so it is stored as that date
+// whatever the connection's time zone
+func quotaDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// lockPair serialises likes between the same pair of users for the rest of the
+// transaction, so two simultaneous likes cannot both miss each other
+func lockPair(tx *gorm.DB, a, b uuid.UUID) error {
+	userA, userB := orderPair(a, b)
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", userA.String()+userB.String()).Error
+}
+
+// matchIfMutual creates the match between the user and the target, along with its
+// conversation, when the target already liked the user. It returns nil when there is no reciprocal like, the
+// pair was already matched or one of them blocked the other. The caller must hold the pair lock.
+func matchIfMutual(tx *gorm.DB, userID, targetUserID uuid.UUID) (*models.Match, error) {
+	var reciprocal int64
+	err := tx.Model(&models.Swipe{}).
+		Where("user_id = ? AND target_user_id = ? AND swipe_type <> ?", targetUserID, userID, models.SwipeTypePass).
+		Count(&reciprocal).Error
+	if err != nil || reciprocal == 0 {
+		return nil, err
+	}
+
+	blocked, err := blockedBetween(tx, userID, targetUserID)
+	if err != nil || blocked {
+		return nil, err
+	}
+
+	userA, userB := orderPair(userID, targetUserID)
+	m := &models.Match{
+		UserAID:   userA,
+		UserBID:   userB,
+		MatchedAt: time.Now(),
+		Status:    models.MatchStatusActive,
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(m)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	// The pair was already matched
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	if err := tx.Omit("Match").Create(&models.Conversation{MatchID: m.ID}).Error; err != nil {
+		return nil, err
+	}
+	return m, nil
 }
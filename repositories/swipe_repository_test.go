@@ -0,0 +1,515 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"datingApp/models"
+)
+
+func TestRecordSwipe(t *testing.T) {
+	db := testDB(t)
+	repo := NewSwipeRepo(db)
+	user, target := createTestUser(t, db), createTestUser(t, db)
+	charge := QuotaCharge{Day: time.Now()}
+
+	match, err := repo.RecordSwipe(user.ID, target.ID, models.SwipeTypePass, nil, charge)
+	if err != nil {
+		t.Fatalf("RecordSwipe: %v", err)
+	}
+	if match != nil {
+		t.Errorf("a pass created a match: %+v", match)
+	}
+
+	swipe, err := repo.GetSwipe(user.ID, target.ID)
+	if err != nil {
+		t.Fatalf("GetSwipe: %v", err)
+	}
+	if swipe == nil {
+		t.Fatal("the swipe was not stored")
+	}
+	if swipe.SwipeType != models.SwipeTypePass || swipe.SwipeDate.IsZero() {
+		t.Errorf("stored swipe = %+v", swipe)
+	}
+
+	_, err = repo.RecordSwipe(user.ID, target.ID, models.SwipeTypeLike, nil, charge)
+	if !errors.Is(err, ErrSwipeExists) {
+		t.Errorf("second swipe error = %v, want %v", err, ErrSwipeExists)
+	}
+}
+
+func TestRecordSwipeMutualLikeCreatesMatch(t *testing.T) {
+	db := testDB(t)
+	repo := NewSwipeRepo(db)
+	alice, bob := createTestUser(t, db), createTestUser(t, db)
+	charge := QuotaCharge{Day: time.Now()}
+
+	match, err := repo.RecordSwipe(alice.ID, bob.ID, models.SwipeTypeLike, nil, charge)
+	if err != nil {
+		t.Fatalf("RecordSwipe: %v", err)
+	}
+	if match != nil {
+		t.Fatalf("a one-sided like created a match: %+v", match)
+	}
+
+	match, err = repo.RecordSwipe(bob.ID, alice.ID, models.SwipeTypeSuperLike, nil, QuotaCharge{Day: time.Now(), Super: true})
+	if err != nil {
+		t.Fatalf("RecordSwipe: %v", err)
+	}
+	if match == nil {
+		t.Fatal("the mutual like did not create a match")
+	}
+	if match.Status != models.MatchStatusActive {
+		t.Errorf("match status = %q, want %q", match.Status, models.MatchStatusActive)
+	}
+
+	var conversations int64
+	if err := db.Model(&models.Conversation{}).Where("match_id = ?", match.ID).Count(&conversations).Error; err != nil {
+		t.Fatal(err)
+	}
+	if conversations != 1 {
+		t.Errorf("conversations for the match = %d, want 1", conversations)
+	}
+}
+
+func TestConcurrentMutualLikesCreateOneMatch(t *testing.T) {
+	db := testDB(t)
+	repo := NewSwipeRepo(db)
+	alice, bob := createTestUser(t, db), createTestUser(t, db)
+	charge := QuotaCharge{Day: time.Now()}
+
+	// Both users like each other at the same moment; one of the likes sees the other
+	start := make(chan struct{})
+	matches := make(chan *models.Match, 2)
+	for _, pair := range [][2]uuid.UUID{{alice.ID, bob.ID}, {bob.ID, alice.ID}} {
+		go func() {
+			<-start
+			match, err := repo.RecordSwipe(pair[0], pair[1], models.SwipeTypeLike, nil, charge)
+			if err != nil {
+				t.Errorf("RecordSwipe: %v", err)
+			}
+			matches <- match
+		}()
+	}
+	close(start)
+
+	created := 0
+	for range 2 {
+		if <-matches != nil {
+			created++
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d swipes reported a match, want 1", created)
+	}
+	var stored int64
+	if err := db.Model(&models.Match{}).Count(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored != 1 {
+		t.Errorf("%d matches stored, want 1", stored)
+	}
+}
+
+func TestRecordSwipeCountsQuotaPerDay(t *testing.T) {
+	db := testDB(t)
+	repo := NewSwipeRepo(db)
+	user := createTestUser(t, db)
+	today := time.Date(2026, time.March, 10, 23, 30, 0, 0, time.FixedZone("UTC+14", 14*60*60))
+	tomorrow := today.AddDate(0, 0, 1)
+
+	for i := 0; i < 3; i++ {
+		if _, err := repo.RecordSwipe(user.ID, createTestUser(t, db).ID, models.SwipeTypePass, nil, QuotaCharge{Day: today}); err != nil {
+			t.Fatalf("RecordSwipe: %v", err)
+		}
+	}
+	if _, err := repo.RecordSwipe(user.ID, createTestUser(t, db).ID, models.SwipeTypeSuperLike, nil, QuotaCharge{Day: today, Super: true}); err != nil {
+		t.Fatalf("RecordSwipe: %v", err)
+	}
+	if _, err := repo.RecordSwipe(user.ID, createTestUser(t, db).ID, models.SwipeTypeLike, nil, QuotaCharge{Day: tomorrow}); err != nil {
+		t.Fatalf("RecordSwipe: %v", err)
+	}
+
+	quota, err := repo.GetQuota(user.ID, today)
+	if err != nil {
+		t.Fatalf("GetQuota: %v", err)
+	}
+	if quota.Used != 3 || quota.SuperUsed != 1 {
+		t.Errorf("today's quota = %d swipes, %d super likes, want 3 and 1", quota.Used, quota.SuperUsed)
+	}
+
+	quota, err = repo.GetQuota(user.ID, tomorrow)
+	if err != nil {
+		t.Fatalf("GetQuota: %v", err)
+	}
+	if quota.Used != 1 || quota.SuperUsed != 0 {
+		t.Errorf("tomorrow's quota = %d swipes, %d super likes, want 1 and 0", quota.Used, quota.SuperUsed)
+	}
+
+	quota, err = repo.GetQuota(user.ID, today.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("GetQuota: %v", err)
+	}
+	if quota.Used != 0 || quota.SuperUsed != 0 {
+		t.Errorf("quota of a day without swipes = %+v, want zero", quota)
+	}
+}
+
+func TestGetCandidatesExclusions(t *testing.T) {
+	db := testDB(t)
+	swipes, users := NewSwipeRepo(db), NewUserRepo(db)
+	charge := QuotaCharge{Day: time.Now()}
+
+	user := createTestUser(t, db)
+	liked := createTestUser(t, db)
+	passed := createTestUser(t, db)
+	matched := createTestUser(t, db)
+	blocked := createTestUser(t, db)
+	blockedBy := createTestUser(t, db)
+	hidden := createTestUser(t, db)
+	fresh := createTestUser(t, db)
+	superLiker := createTestUser(t, db)
+
+	if _, err := swipes.RecordSwipe(user.ID, liked.ID, models.SwipeTypeLike, nil, charge); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := swipes.RecordSwipe(user.ID, passed.ID, models.SwipeTypePass, nil, charge); err != nil {
+		t.Fatal(err)
+	}
+	// An ended match still keeps the pair apart
+	userA, userB := orderPair(user.ID, matched.ID)
+	endedAt := time.Now()
+	if err := db.Create(&models.Match{
+		UserAID: userA, UserBID: userB, MatchedAt: endedAt, Status: models.MatchStatusUnmatched, EndedAt: &endedAt,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	blocks := NewBlockRepo(db)
+	if err := blocks.BlockUser(user.ID, blocked.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := blocks.BlockUser(blockedBy.ID, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.User{}).Where("id = ?", hidden.ID).Update("hidden_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := swipes.RecordSwipe(superLiker.ID, user.ID, models.SwipeTypeSuperLike, nil, QuotaCharge{Day: time.Now(), Super: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	candidates, err := users.GetCandidates(user.ID, nil, nil, 50)
+	if err != nil {
+		t.Fatalf("GetCandidates: %v", err)
+	}
+	got := candidateIDs(candidates)
+	want := []uuid.UUID{superLiker.ID, fresh.ID}
+	if !equalIDs(got, want) {
+		t.Errorf("candidates = %v, want %v", got, want)
+	}
+	if len(candidates) > 0 && !candidates[0].SuperLikedYou {
+		t.Error("the super liker is not flagged")
+	}
+
+	// Once passes are recycled the passed user comes back, but nobody else does
+	recycleBefore := time.Now().Add(time.Hour)
+	candidates, err = users.GetCandidates(user.ID, &recycleBefore, nil, 50)
+	if err != nil {
+		t.Fatalf("GetCandidates: %v", err)
+	}
+	got = candidateIDs(candidates)
+	want = []uuid.UUID{superLiker.ID, passed.ID, fresh.ID}
+	if !equalIDs(got, want) {
+		t.Errorf("candidates with recycled passes = %v, want %v", got, want)
+	}
+
+	// Swiping on the recycled user replaces the pass, so they drop out of the deck
+	// again, and the new swipe is charged like any other
+	if _, err := swipes.RecordSwipe(user.ID, passed.ID, models.SwipeTypeLike, &recycleBefore, charge); err != nil {
+		t.Fatalf("swiping on a recycled pass: %v", err)
+	}
+	candidates, err = users.GetCandidates(user.ID, &recycleBefore, nil, 50)
+	if err != nil {
+		t.Fatalf("GetCandidates: %v", err)
+	}
+	got = candidateIDs(candidates)
+	want = []uuid.UUID{superLiker.ID, fresh.ID}
+	if !equalIDs(got, want) {
+		t.Errorf("candidates after swiping on the recycled user = %v, want %v", got, want)
+	}
+	quota, err := swipes.GetQuota(user.ID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quota.Used != 3 {
+		t.Errorf("quota used = %d, want 3", quota.Used)
+	}
+	swipe, err := swipes.GetSwipe(user.ID, passed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swipe.SwipeType != models.SwipeTypeLike {
+		t.Errorf("swipe type = %s, want %s", swipe.SwipeType, models.SwipeTypeLike)
+	}
+
+	// Only passes are recycled
+	if _, err := swipes.RecordSwipe(user.ID, passed.ID, models.SwipeTypePass, &recycleBefore, charge); !errors.Is(err, ErrSwipeExists) {
+		t.Errorf("swiping on a liked user error = %v, want %v", err, ErrSwipeExists)
+	}
+}
+
+func candidateIDs(candidates []models.Candidate) []uuid.UUID {
+	ids := make([]uuid.UUID, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []uuid.UUID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSwipeQuotaTable(t *testing.T) {
+	db := testDB(t)
+	user, target := createTestUser(t, db), createTestUser(t, db)
+	day := time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)
+
+	if _, err := NewSwipeRepo(db).RecordSwipe(user.ID, target.ID, models.SwipeTypePass, nil, QuotaCharge{Day: day}); err != nil {
+		t.Fatalf("RecordSwipe: %v", err)
+	}
+
+	var used int
+	if err := db.Raw("SELECT used FROM swipe_quotas WHERE user_id = ? AND day = ?", user.ID, day).Scan(&used).Error; err != nil {
+		t.Fatalf("reading swipe_quotas: %v", err)
+	}
+	if used != 1 {
+		t.Errorf("swipe_quotas.used = %d, want 1", used)
+	}
+}
+
+func TestRecordSwipeConcurrentQuota(t *testing.T) {
+	tests := []struct {
+		name      string
+		swipeType string
+		super     bool
+		exceeded  error
+	}{
+		{"swipes", models.SwipeTypePass, false, ErrQuotaExceeded},
+		{"super likes", models.SwipeTypeSuperLike, true, ErrSuperLikesExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const limit, attempts = 10, 25
+
+			db := testDB(t)
+			repo := NewSwipeRepo(db)
+			user := createTestUser(t, db)
+			targets := make([]uuid.UUID, attempts)
+			for i := range targets {
+				targets[i] = createTestUser(t, db).ID
+			}
+			dailyLimit := limit
+			charge := QuotaCharge{Day: time.Now(), Limit: &dailyLimit, Super: tt.super}
+
+			start := make(chan struct{})
+			errs := make(chan error, attempts)
+			for _, target := range targets {
+				go func(target uuid.UUID) {
+					<-start
+					_, err := repo.RecordSwipe(user.ID, target, tt.swipeType, nil, charge)
+					errs <- err
+				}(target)
+			}
+			close(start)
+
+			succeeded := 0
+			for range targets {
+				err := <-errs
+				switch {
+				case err == nil:
+					succeeded++
+				case !errors.Is(err, tt.exceeded):
+					t.Errorf("RecordSwipe error = %v, want nil or %v", err, tt.exceeded)
+				}
+			}
+			if succeeded != limit {
+				t.Errorf("%d swipes succeeded, want %d", succeeded, limit)
+			}
+
+			quota, err := repo.GetQuota(user.ID, charge.Day)
+			if err != nil {
+				t.Fatalf("GetQuota: %v", err)
+			}
+			used := quota.Used
+			if tt.super {
+				used = quota.SuperUsed
+			}
+			if used != limit {
+				t.Errorf("quota used = %d, want %d", used, limit)
+			}
+
+			// Rejected swipes must not be stored either
+			var stored int64
+			if err := db.Model(&models.Swipe{}).Where("user_id = ?", user.ID).Count(&stored).Error; err != nil {
+				t.Fatal(err)
+			}
+			if stored != limit {
+				t.Errorf("%d swipes stored, want %d", stored, limit)
+			}
+		})
+	}
+}
+
+func TestQuotaDay(t *testing.T) {
+	kiritimati := time.FixedZone("UTC+14", 14*60*60)
+	pagoPago := time.FixedZone("UTC-11", -11*60*60)
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"utc", time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC), time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)},
+		{"ahead of utc", time.Date(2026, time.March, 11, 2, 0, 0, 0, kiritimati), time.Date(2026, time.March, 11, 0, 0, 0, 0, time.UTC)},
+		{"behind utc", time.Date(2026, time.March, 10, 1, 0, 0, 0, pagoPago), time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quotaDay(tt.t); !got.Equal(tt.want) {
+				t.Errorf("quotaDay(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUndoLastSwipeEndsMatch(t *testing.T) {
+	db := testDB(t)
+	repo := NewSwipeRepo(db)
+	alice, bob := createTestUser(t, db), createTestUser(t, db)
+	charge := QuotaCharge{Day: time.Now()}
+
+	if _, err := repo.RecordSwipe(alice.ID, bob.ID, models.SwipeTypeLike, nil, charge); err != nil {
+		t.Fatal(err)
+	}
+	match, err := repo.RecordSwipe(bob.ID, alice.ID, models.SwipeTypeLike, nil, charge)
+	if err != nil || match == nil {
+		t.Fatalf("RecordSwipe = %v, %v, want a match", match, err)
+	}
+
+	now := time.Now()
+	undone, ended, err := repo.UndoLastSwipe(bob.ID, now.Add(-time.Minute), now, 5)
+	if err != nil {
+		t.Fatalf("UndoLastSwipe: %v", err)
+	}
+	if undone.TargetUserID != alice.ID {
+		t.Errorf("undid the swipe on %s, want %s", undone.TargetUserID, alice.ID)
+	}
+	if ended == nil || ended.ID != match.ID {
+		t.Fatalf("ended match = %v, want %s", ended, match.ID)
+	}
+
+	stored, err := NewMatchRepo(db).GetMatchByID(match.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("GetMatchByID = %v, %v", stored, err)
+	}
+	if stored.Status != models.MatchStatusUnmatched || stored.EndedByID == nil || *stored.EndedByID != bob.ID || stored.EndedAt == nil {
+		t.Errorf("match is %s, ended by %v at %v; want unmatched by %s", stored.Status, stored.EndedByID, stored.EndedAt, bob.ID)
+	}
+	// The conversation is kept with the ended match
+	var conversations int64
+	if err := db.Model(&models.Conversation{}).Where("match_id = ?", match.ID).Count(&conversations).Error; err != nil {
+		t.Fatal(err)
+	}
+	if conversations != 1 {
+		t.Errorf("%d conversations left for the match, want 1", conversations)
+	}
+
+	// Undoing a pass ends nothing
+	if _, err := repo.RecordSwipe(bob.ID, createTestUser(t, db).ID, models.SwipeTypePass, nil, charge); err != nil {
+		t.Fatal(err)
+	}
+	if _, ended, err := repo.UndoLastSwipe(bob.ID, now.Add(-time.Minute), now, 5); err != nil || ended != nil {
+		t.Errorf("undoing a pass = %v, %v, want no ended match", ended, err)
+	}
+}
+
+func TestGetLikesReceived(t *testing.T) {
+	db := testDB(t)
+	swipes := NewSwipeRepo(db)
+	charge := QuotaCharge{Day: time.Now()}
+
+	user := createTestUser(t, db)
+	liker := createTestUser(t, db)
+	superLiker := createTestUser(t, db)
+	passer := createTestUser(t, db)
+	answered := createTestUser(t, db)
+	blocked := createTestUser(t, db)
+	hidden := createTestUser(t, db)
+
+	for _, like := range []struct {
+		from      *models.User
+		swipeType string
+	}{
+		{liker, models.SwipeTypeLike},
+		{superLiker, models.SwipeTypeSuperLike},
+		{passer, models.SwipeTypePass},
+		{answered, models.SwipeTypeLike},
+		{blocked, models.SwipeTypeLike},
+		{hidden, models.SwipeTypeLike},
+	} {
+		if _, err := swipes.RecordSwipe(like.from.ID, user.ID, like.swipeType, nil, charge); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := swipes.RecordSwipe(user.ID, answered.ID, models.SwipeTypePass, nil, charge); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewBlockRepo(db).BlockUser(user.ID, blocked.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.User{}).Where("id = ?", hidden.ID).Update("hidden_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := swipes.CountLikesReceived(user.ID)
+	if err != nil {
+		t.Fatalf("CountLikesReceived: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("count = %d, want 2", count)
+	}
+
+	// Newest first, one per page
+	page, err := swipes.GetLikesReceived(user.ID, nil, 1)
+	if err != nil {
+		t.Fatalf("GetLikesReceived: %v", err)
+	}
+	if len(page) != 1 || page[0].UserID != superLiker.ID {
+		t.Fatalf("first page = %+v, want the super like", page)
+	}
+	after := &models.Cursor{CreatedAt: page[0].CreatedAt, ID: page[0].ID}
+	page, err = swipes.GetLikesReceived(user.ID, after, 1)
+	if err != nil {
+		t.Fatalf("GetLikesReceived: %v", err)
+	}
+	if len(page) != 1 || page[0].UserID != liker.ID {
+		t.Fatalf("second page = %+v, want the like", page)
+	}
+	after = &models.Cursor{CreatedAt: page[0].CreatedAt, ID: page[0].ID}
+	if page, err = swipes.GetLikesReceived(user.ID, after, 1); err != nil || len(page) != 0 {
+		t.Errorf("page after the last like = %+v, %v; want none", page, err)
+	}
+}
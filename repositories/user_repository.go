@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
@@ -9,9 +11,12 @@ import (
 
 type UserRepository interface {
 	GetUserByID(userID uuid.UUID) (*models.User, error)
-	GetUnswipedUsers(userID uuid.UUID, swipedIDs []uuid.UUID) ([]models.User, error)
+	GetCandidates(userID uuid.UUID, passRecycleBefore *time.Time, after *models.Cursor, limit int) ([]models.Candidate, error)
 	GetUserByEmail(email string) (*models.User, error)
+	GetUserByUsername(username string) (*models.User, error)
 	CreateUser(user *models.User) error // New method to create user
+	CreateUserWithProfile(user *models.User, profile *models.Profile) error
+	SetVerified(userID uuid.UUID, verified bool) error
 }
 
 type UserRepo struct {
@@ -24,20 +29,56 @@ func NewUserRepo(db *gorm.DB) *UserRepo {
 
 func (r *UserRepo) GetUserByID(userID uuid.UUID) (*models.User, error) {
 	var user models.User
-	err := r.DB.First(&user, userID).Error
+	err := r.DB.Where("id = ?", userID).First(&user).Error
 	return &user, err
 }
 
-func (r *UserRepo) GetUnswipedUsers(userID uuid.UUID, swipedIDs []uuid.UUID) ([]models.User, error) {
-	var users []models.User
+// superLikedYou is true for candidates who super liked the user discovery runs for
+const superLikedYou = `EXISTS (
+	SELECT 1 FROM swipes AS super_likes
+	WHERE super_likes.user_id = users.id AND super_likes.target_user_id = ?
+	  AND super_likes.swipe_type = 'super_like'
+)`
+
+// GetCandidates returns up to limit users the given user has never swiped on, starting
+// after the cursor. Users who super liked the given user come first, then the list is
+// ordered by (created_at, id). Passes made before passRecycleBefore no longer exclude
+// a user; with a nil time passes never expire. Profiles hidden by moderation are left out.
+func (r *UserRepo) GetCandidates(userID uuid.UUID, passRecycleBefore *time.Time, after *models.Cursor, limit int) ([]models.Candidate, error) {
+	var candidates []models.Candidate
 
-	query := r.DB.Where("id != ?", userID)
-	if len(swipedIDs) > 0 {
-		query = query.Where("id NOT IN ?", swipedIDs)
+	query := r.DB.Model(&models.User{}).
+		Select("users.*, "+superLikedYou+" AS super_liked_you", userID).
+		Where("users.id != ? AND users.hidden_at IS NULL", userID)
+	if passRecycleBefore != nil {
+		query = query.Where(`NOT EXISTS (
+			SELECT 1 FROM swipes
+			WHERE swipes.user_id = ? AND swipes.target_user_id = users.id
+			  AND (swipes.swipe_type <> ? OR swipes.swipe_date >= ?)
+		)`, userID, models.SwipeTypePass, *passRecycleBefore)
+	} else {
+		query = query.Where(`NOT EXISTS (
+			SELECT 1 FROM swipes
+			WHERE swipes.user_id = ? AND swipes.target_user_id = users.id
+		)`, userID)
+	}
+	// Matched users, including ones whose match ended, are never shown again
+	query = query.Where(`NOT EXISTS (
+		SELECT 1 FROM matches
+		WHERE (matches.user_a_id = ? AND matches.user_b_id = users.id)
+		   OR (matches.user_b_id = ? AND matches.user_a_id = users.id)
+	)`, userID, userID)
+	query = query.Where(notBlockedWith("users.id"), userID, userID)
+	if after != nil && after.SuperLiked {
+		query = query.Where("("+superLikedYou+" AND (users.created_at, users.id) > (?, ?)) OR NOT "+superLikedYou,
+			userID, after.CreatedAt, after.ID, userID)
+	} else if after != nil {
+		query = query.Where("NOT "+superLikedYou+" AND (users.created_at, users.id) > (?, ?)",
+			userID, after.CreatedAt, after.ID)
 	}
 
-	err := query.Find(&users).Error
-	return users, err
+	err := query.Order("super_liked_you DESC, users.created_at, users.id").Limit(limit).Find(&candidates).Error
+	return candidates, err
 }
 
 func (r *UserRepo) GetUserByEmail(email string) (*models.User, error) {
@@ -46,8 +87,30 @@ func (r *UserRepo) GetUserByEmail(email string) (*models.User, error) {
 	return &user, err
 }
 
+func (r *UserRepo) GetUserByUsername(username string) (*models.User, error) {
+	var user models.User
+	err := r.DB.Where("username = ?", username).First(&user).Error
+	return &user, err
+}
+
 // New method to create a new user
 func (r *UserRepo) CreateUser(user *models.User) error {
 	result := r.DB.Create(user)
 	return result.Error
 }
+
+// CreateUserWithProfile creates the user and their profile in a single transaction
+func (r *UserRepo) CreateUserWithProfile(user *models.User, profile *models.Profile) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		profile.UserID = user.ID
+		return tx.Omit("User").Create(profile).Error
+	})
+}
+
+// SetVerified turns the user's verified badge on or off
+func (r *UserRepo) SetVerified(userID uuid.UUID, verified bool) error {
+	return r.DB.Model(&models.User{}).Where("id = ?", userID).Update("is_verified", verified).Error
+}
@@ -0,0 +1,132 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/middleware"
+	"datingApp/models"
+	"datingApp/services"
+)
+
+func RegisterAdminRoutes(router *gin.Engine, rbacService *services.RBACService, authMiddleware gin.HandlerFunc) {
+	admin := router.Group("/admin")
+	admin.Use(authMiddleware)
+	canManageRoles := middleware.RequirePermission(rbacService, models.PermissionManageRoles)
+	canViewUsers := middleware.RequirePermission(rbacService, models.PermissionViewUsers)
+	canRevokeSessions := middleware.RequirePermission(rbacService, models.PermissionRevokeSessions)
+	{
+		// List roles and the permissions they grant
+		admin.GET("/roles", canManageRoles, func(c *gin.Context) {
+			roles, err := rbacService.GetRoles()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roles"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"roles": roles})
+		})
+
+		// List a user's roles
+		admin.GET("/users/:userID/roles", canViewUsers, func(c *gin.Context) {
+			userID, err := uuid.Parse(c.Param("userID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+				return
+			}
+
+			roles, err := rbacService.GetUserRoles(userID)
+			if errors.Is(err, services.ErrUserNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user roles"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"roles": roles})
+		})
+
+		// Grant a role to a user
+		admin.POST("/users/:userID/roles", canManageRoles, func(c *gin.Context) {
+			actorID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			userID, err := uuid.Parse(c.Param("userID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+				return
+			}
+
+			var req models.AssignRoleRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			err = rbacService.AssignRole(actorID, userID, req.Role)
+			if errors.Is(err, services.ErrUserNotFound) || errors.Is(err, services.ErrRoleNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign role"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"message": "Role assigned successfully"})
+		})
+
+		// Revoke a role from a user
+		admin.DELETE("/users/:userID/roles/:role", canManageRoles, func(c *gin.Context) {
+			userID, err := uuid.Parse(c.Param("userID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+				return
+			}
+
+			err = rbacService.RemoveRole(userID, c.Param("role"))
+			if errors.Is(err, services.ErrRoleNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrLastSuperAdmin) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove role"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"message": "Role removed successfully"})
+		})
+
+		// Log a user out of every session
+		admin.POST("/users/:userID/sessions/revoke", canRevokeSessions, func(c *gin.Context) {
+			userID, err := uuid.Parse(c.Param("userID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+				return
+			}
+
+			err = rbacService.RevokeUserSessions(userID)
+			if errors.Is(err, services.ErrUserNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"message": "Sessions revoked successfully"})
+		})
+	}
+}
@@ -1,21 +1,23 @@
 package routes
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"datingApp/models"
 	"datingApp/services"
 )
 
-func RegisterAuthRoutes(router *gin.Engine, authService *services.AuthService) {
+func RegisterAuthRoutes(router *gin.Engine, authService *services.AuthService, authMiddleware gin.HandlerFunc) {
 	authGroup := router.Group("/auth")
 	{
 		authGroup.POST("/signup", func(c *gin.Context) {
 			var userRequest models.SignUpRequest
 			if err := c.ShouldBindJSON(&userRequest); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 				return
 			}
 
@@ -44,5 +46,42 @@ func RegisterAuthRoutes(router *gin.Engine, authService *services.AuthService) {
 
 			c.JSON(http.StatusOK, gin.H{"user": user})
 		})
+
+		// Exchange a refresh token for a new access and refresh token pair
+		authGroup.POST("/refresh", func(c *gin.Context) {
+			var req models.RefreshRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+				return
+			}
+
+			tokens, err := authService.Refresh(req.RefreshToken)
+			if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"user": tokens})
+		})
+
+		// Revoke the caller's session
+		authGroup.POST("/logout", authMiddleware, func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+			sessionID := c.MustGet("sessionID").(uuid.UUID)
+
+			if err := authService.Logout(userID, sessionID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+		})
 	}
 }
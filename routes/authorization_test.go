@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/middleware"
+	"datingApp/models"
+	"datingApp/services"
+	"datingApp/tokens"
+)
+
+// sessionSet reports the sessions it holds as active
+type sessionSet map[uuid.UUID]bool
+
+func (s sessionSet) IsSessionActive(sessionID uuid.UUID) (bool, error) {
+	return s[sessionID], nil
+}
+
+func TestRouteAuthorization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key, err := tokens.GenerateHMACKey("test")
+	if err != nil {
+		t.Fatalf("GenerateHMACKey: %v", err)
+	}
+	keys, err := tokens.NewKeySet("test", key)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+
+	member := &models.User{ID: uuid.New()}
+	packageManager := &models.User{ID: uuid.New()}
+	support := &models.User{ID: uuid.New()}
+	roleAdmin := &models.User{ID: uuid.New()}
+	users := newMemoryUserRepo(member, packageManager, support, roleAdmin)
+	roles := &memoryRoleRepo{permissions: map[uuid.UUID][]string{
+		packageManager.ID: {models.PermissionManagePackages},
+		support.ID:        {models.PermissionViewPremiumStatus, models.PermissionViewUsers},
+		roleAdmin.ID:      {models.PermissionManageRoles},
+	}}
+	rbacService := services.NewRBACService(roles, users, nil, nil)
+	premiumService := services.NewPremiumService(newMemoryPremiumRepo(), users, nil)
+
+	sessions := sessionSet{}
+	bearer := func(user *models.User, expiresAt time.Time, active bool) string {
+		sessionID := uuid.New()
+		sessions[sessionID] = active
+		token, err := keys.Sign(jwt.MapClaims{"user_id": user.ID, "sid": sessionID, "exp": expiresAt.Unix()})
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return "Bearer " + token
+	}
+	later := time.Now().Add(time.Hour)
+	asMember := bearer(member, later, true)
+	asPackageManager := bearer(packageManager, later, true)
+	asSupport := bearer(support, later, true)
+	asRoleAdmin := bearer(roleAdmin, later, true)
+	expired := bearer(member, time.Now().Add(-time.Minute), true)
+	loggedOut := bearer(member, later, false)
+
+	router := gin.New()
+	authMiddleware := middleware.JWTAuth(keys, sessions)
+	RegisterPremiumRoutes(router, premiumService, authMiddleware, rbacService)
+	RegisterAdminRoutes(router, rbacService, authMiddleware)
+
+	newPackage := `{"name":"Gold","description":"Monthly gold","price":"9.99","duration_months":1}`
+	purchase := `{"package_id":"` + uuid.NewString() + `"}`
+	memberStatus := "/premium/status/" + member.ID.String()
+	memberRoles := "/admin/users/" + member.ID.String() + "/roles"
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		body          string
+		authorization string
+		wantStatus    int
+	}{
+		{"packages are public", http.MethodGet, "/premium/packages", "", "", http.StatusOK},
+		{"purchase without a token", http.MethodPost, "/premium/purchase", purchase, "", http.StatusUnauthorized},
+		{"purchase with a malformed header", http.MethodPost, "/premium/purchase", purchase, "Token abc", http.StatusUnauthorized},
+		{"purchase with a forged token", http.MethodPost, "/premium/purchase", purchase, "Bearer not.a.token", http.StatusUnauthorized},
+		{"purchase with an expired token", http.MethodPost, "/premium/purchase", purchase, expired, http.StatusUnauthorized},
+		{"purchase after logging out", http.MethodPost, "/premium/purchase", purchase, loggedOut, http.StatusUnauthorized},
+		{"purchase as a member", http.MethodPost, "/premium/purchase", purchase, asMember, http.StatusNotFound},
+		{"own entitlements", http.MethodGet, "/premium/entitlements", "", asMember, http.StatusOK},
+		{"own status", http.MethodGet, "/premium/status", "", asMember, http.StatusOK},
+		{"create package without a token", http.MethodPost, "/premium/packages", newPackage, "", http.StatusUnauthorized},
+		{"create package as a member", http.MethodPost, "/premium/packages", newPackage, asMember, http.StatusForbidden},
+		{"create package as support", http.MethodPost, "/premium/packages", newPackage, asSupport, http.StatusForbidden},
+		{"create package as package manager", http.MethodPost, "/premium/packages", newPackage, asPackageManager, http.StatusCreated},
+		{"update package as a member", http.MethodPut, "/premium/packages/" + uuid.NewString(), newPackage, asMember, http.StatusForbidden},
+		{"delete package as a member", http.MethodDelete, "/premium/packages/" + uuid.NewString(), "", asMember, http.StatusForbidden},
+		{"delete package as package manager", http.MethodDelete, "/premium/packages/" + uuid.NewString(), "", asPackageManager, http.StatusNotFound},
+		{"another user's status as a member", http.MethodGet, memberStatus, "", asMember, http.StatusForbidden},
+		{"another user's status as support", http.MethodGet, memberStatus, "", asSupport, http.StatusOK},
+		{"roles without a token", http.MethodGet, "/admin/roles", "", "", http.StatusUnauthorized},
+		{"roles as a member", http.MethodGet, "/admin/roles", "", asMember, http.StatusForbidden},
+		{"roles as package manager", http.MethodGet, "/admin/roles", "", asPackageManager, http.StatusForbidden},
+		{"roles as role admin", http.MethodGet, "/admin/roles", "", asRoleAdmin, http.StatusOK},
+		{"user roles as a member", http.MethodGet, memberRoles, "", asMember, http.StatusForbidden},
+		{"user roles as support", http.MethodGet, memberRoles, "", asSupport, http.StatusOK},
+		{"grant role as support", http.MethodPost, memberRoles, `{"role":"admin"}`, asSupport, http.StatusForbidden},
+		{"revoke sessions as support", http.MethodPost, "/admin/users/" + member.ID.String() + "/sessions/revoke", "", asSupport, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.authorization != "" {
+				header.Set("Authorization", tt.authorization)
+			}
+			w := serve(router, tt.method, tt.path, tt.body, header)
+			if w.Code != tt.wantStatus {
+				t.Errorf("%s %s: status = %d, want %d: %s", tt.method, tt.path, w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
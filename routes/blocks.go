@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/services"
+)
+
+func RegisterBlockRoutes(router *gin.Engine, blockService *services.BlockService, authMiddleware gin.HandlerFunc) {
+	blockGroup := router.Group("/blocks")
+	blockGroup.Use(authMiddleware)
+	{
+		// List the users the caller blocked
+		blockGroup.GET("", func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			blocks, err := blockService.GetBlockedUsers(userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blocked users"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"blocks": blocks})
+		})
+
+		// Block a user, which also ends any match with them
+		blockGroup.POST("/:userID", func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			blockedID, err := uuid.Parse(c.Param("userID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+				return
+			}
+
+			err = blockService.BlockUser(userID, blockedID)
+			if errors.Is(err, services.ErrCannotBlockSelf) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrUserNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"message": "User blocked successfully"})
+		})
+
+		// Lift a block
+		blockGroup.DELETE("/:userID", func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			blockedID, err := uuid.Parse(c.Param("userID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+				return
+			}
+
+			err = blockService.UnblockUser(userID, blockedID)
+			if errors.Is(err, services.ErrBlockNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User is not blocked"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"message": "User unblocked successfully"})
+		})
+	}
+}
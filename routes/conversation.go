@@ -0,0 +1,157 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/models"
+	"datingApp/services"
+)
+
+func RegisterConversationRoutes(router *gin.Engine, messageService *services.MessageService, authMiddleware gin.HandlerFunc,
+	idempotency gin.HandlerFunc) {
+	conversationGroup := router.Group("/conversations")
+	conversationGroup.Use(authMiddleware)
+	{
+		// List the caller's conversations with their unread counts
+		conversationGroup.GET("", func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			conversations, err := messageService.GetConversations(userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch conversations"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"conversations": conversations})
+		})
+
+		// Page through a conversation's history, newest first
+		conversationGroup.GET("/:conversationID/messages", func(c *gin.Context) {
+			userID, conversationID, ok := conversationParams(c)
+			if !ok {
+				return
+			}
+
+			limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+			if err != nil || limit < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+				return
+			}
+
+			page, err := messageService.GetMessages(userID, conversationID, c.Query("cursor"), limit)
+			if errors.Is(err, models.ErrInvalidCursor) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+				return
+			}
+			if errors.Is(err, services.ErrConversationNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
+				return
+			}
+
+			c.JSON(http.StatusOK, page)
+		})
+
+		conversationGroup.POST("/:conversationID/messages", idempotency, func(c *gin.Context) {
+			userID, conversationID, ok := conversationParams(c)
+			if !ok {
+				return
+			}
+
+			var req models.SendMessageRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+				return
+			}
+
+			message, err := messageService.SendMessage(userID, conversationID, req.Body)
+			if errors.Is(err, services.ErrConversationNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message"})
+				return
+			}
+
+			c.JSON(http.StatusCreated, message)
+		})
+
+		// Mark everything received so far as read
+		conversationGroup.POST("/:conversationID/read", func(c *gin.Context) {
+			userID, conversationID, ok := conversationParams(c)
+			if !ok {
+				return
+			}
+
+			err := messageService.MarkRead(userID, conversationID)
+			if errors.Is(err, services.ErrConversationNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark conversation as read"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"message": "Conversation marked as read"})
+		})
+
+		// Delete one of the caller's own messages
+		conversationGroup.DELETE("/:conversationID/messages/:messageID", func(c *gin.Context) {
+			userID, conversationID, ok := conversationParams(c)
+			if !ok {
+				return
+			}
+
+			messageID, err := uuid.Parse(c.Param("messageID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID format"})
+				return
+			}
+
+			err = messageService.DeleteMessage(userID, conversationID, messageID)
+			if errors.Is(err, services.ErrConversationNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+				return
+			}
+			if errors.Is(err, services.ErrMessageNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete message"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"message": "Message deleted successfully"})
+		})
+	}
+}
+
+// conversationParams reads the caller and the conversation ID from the request.
+// On failure it writes the error response and returns false.
+func conversationParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	conversationID, err := uuid.Parse(c.Param("conversationID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID format"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, conversationID, true
+}
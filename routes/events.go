@@ -0,0 +1,122 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/events"
+	"datingApp/realtime"
+	"datingApp/services"
+)
+
+// keepAliveInterval is how often an idle event stream sends a comment so proxies keep it open
+const keepAliveInterval = 30 * time.Second
+
+// RegisterEventRoutes exposes the Server-Sent Events stream, a fallback for clients that
+// cannot keep a WebSocket open. It carries the same events minus typing indicators.
+// A reconnecting client sends Last-Event-ID (or the last_event_id query parameter) and
+// first receives the events it missed; a fresh connection only gets new events.
+func RegisterEventRoutes(router *gin.Engine, eventService *services.EventService, hub *realtime.Hub,
+	streamAuth gin.HandlerFunc) {
+	router.GET("/events", streamAuth, func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+
+		lastEventID := c.GetHeader("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = c.Query("last_event_id")
+		}
+		// A fresh connection starts from the live stream; only a resuming client gets a replay
+		resume := lastEventID != ""
+		var afterID int64
+		if resume {
+			var err error
+			afterID, err = strconv.ParseInt(lastEventID, 10, 64)
+			if err != nil || afterID < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid last event ID"})
+				return
+			}
+		}
+
+		// Listen before reading the log so that no event falls between the two
+		live, stop := hub.Listen(userID, c.MustGet("sessionID").(uuid.UUID))
+		defer stop()
+
+		var missed []events.Event
+		if resume {
+			var err error
+			missed, err = eventService.GetEventsAfter(userID, afterID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+				return
+			}
+		}
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+
+		// A user's events are logged and published in ID order, so lastID is all it
+		// takes to skip what the client already has
+		lastID := afterID
+		for len(missed) > 0 {
+			for _, event := range missed {
+				renderEvent(c, event)
+				lastID = event.ID
+			}
+			c.Writer.Flush()
+			if len(missed) < services.ReplayPageSize {
+				break
+			}
+
+			var err error
+			missed, err = eventService.GetEventsAfter(userID, lastID)
+			if err != nil {
+				// The client resumes from the last event it got once it reconnects
+				return
+			}
+		}
+		c.Writer.Flush()
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case event, ok := <-live:
+				if !ok {
+					// The client fell behind, or its session was revoked; it resumes from its
+					// last event after reconnecting with a valid token
+					return false
+				}
+				// Ephemeral events are not logged and cannot be resumed, and an event
+				// published while the log was read may already have been replayed
+				if event.ID > lastID {
+					renderEvent(c, event)
+					lastID = event.ID
+				}
+				return true
+			case <-keepAlive.C:
+				_, err := io.WriteString(w, ": keep-alive\n\n")
+				return err == nil
+			}
+		})
+	})
+}
+
+func renderEvent(c *gin.Context, event events.Event) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatInt(event.ID, 10),
+		Event: event.Type,
+		Data:  event.Data,
+	})
+}
@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/events"
+	"datingApp/models"
+	"datingApp/realtime"
+	"datingApp/services"
+)
+
+// memoryEventLog is an in-memory user event log that numbers events like a sequence
+type memoryEventLog struct {
+	mu     sync.Mutex
+	events []models.UserEvent
+}
+
+func (l *memoryEventLog) AppendUserEvent(event *models.UserEvent, deliver func() error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	event.ID = int64(len(l.events) + 1)
+	l.events = append(l.events, *event)
+	return deliver()
+}
+
+func (l *memoryEventLog) GetUserEventsAfter(userID uuid.UUID, afterID int64, limit int) ([]models.UserEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	found := []models.UserEvent{}
+	for _, event := range l.events {
+		if event.UserID == userID && event.ID > afterID && len(found) < limit {
+			found = append(found, event)
+		}
+	}
+	return found, nil
+}
+
+func (l *memoryEventLog) DeleteUserEventsBefore(time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestEventStreamReplaysEveryMissedEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userID := uuid.New()
+	bus := events.NewLocalBus()
+	hub := realtime.NewHub(bus, nil)
+	defer hub.Close()
+	eventService := services.NewEventService(&memoryEventLog{}, bus)
+
+	publish := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			event, err := events.New(userID, events.TypeLikeReceived, gin.H{"n": i})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := eventService.Publish(event); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// More events than fit in one replay page were missed
+	missed := 2*services.ReplayPageSize + 10
+	publish(missed)
+
+	router := gin.New()
+	RegisterEventRoutes(router, eventService, hub, func(c *gin.Context) {
+		c.Set("userID", userID.String())
+		c.Set("sessionID", uuid.New())
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "5")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	nextID := func() int64 {
+		t.Helper()
+		for lines.Scan() {
+			if id, ok := strings.CutPrefix(lines.Text(), "id:"); ok {
+				n, err := strconv.ParseInt(id, 10, 64)
+				if err != nil {
+					t.Fatalf("invalid event ID %q", id)
+				}
+				return n
+			}
+		}
+		t.Fatalf("the stream ended: %v", lines.Err())
+		return 0
+	}
+
+	for want := int64(6); want <= int64(missed); want++ {
+		if got := nextID(); got != want {
+			t.Fatalf("replayed event %d, want %d", got, want)
+		}
+	}
+
+	// Once caught up the client gets live events, without repeats
+	publish(1)
+	if got := nextID(); got != int64(missed)+1 {
+		t.Errorf("live event %d, want %d", got, missed+1)
+	}
+}
@@ -0,0 +1,301 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+// memoryUserRepo keeps users in memory
+type memoryUserRepo struct {
+	users map[uuid.UUID]*models.User
+}
+
+var _ repositories.UserRepository = (*memoryUserRepo)(nil)
+
+func newMemoryUserRepo(users ...*models.User) *memoryUserRepo {
+	r := &memoryUserRepo{users: map[uuid.UUID]*models.User{}}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *memoryUserRepo) GetUserByID(userID uuid.UUID) (*models.User, error) {
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *memoryUserRepo) GetCandidates(uuid.UUID, *time.Time, *models.Cursor, int) ([]models.Candidate, error) {
+	return nil, nil
+}
+
+func (r *memoryUserRepo) GetUserByEmail(email string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryUserRepo) GetUserByUsername(username string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryUserRepo) CreateUser(user *models.User) error {
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *memoryUserRepo) CreateUserWithProfile(user *models.User, _ *models.Profile) error {
+	return r.CreateUser(user)
+}
+
+func (r *memoryUserRepo) SetVerified(userID uuid.UUID, verified bool) error {
+	if user, ok := r.users[userID]; ok {
+		user.IsVerified = verified
+	}
+	return nil
+}
+
+// memoryProfileRepo keeps profiles in memory and reads their users from a memoryUserRepo
+type memoryProfileRepo struct {
+	users    *memoryUserRepo
+	profiles map[uuid.UUID]*models.Profile
+}
+
+var _ repositories.ProfileRepository = (*memoryProfileRepo)(nil)
+
+func (r *memoryProfileRepo) GetProfileByUserID(userID uuid.UUID) (*models.Profile, error) {
+	profile, ok := r.profiles[userID]
+	user, found := r.users.users[userID]
+	if !ok || !found {
+		return nil, nil
+	}
+	copied := *profile
+	copied.User = *user
+	return &copied, nil
+}
+
+func (r *memoryProfileRepo) GetProfilesByUserIDs(userIDs []uuid.UUID) ([]models.Profile, error) {
+	profiles := []models.Profile{}
+	for _, userID := range userIDs {
+		if profile, _ := r.GetProfileByUserID(userID); profile != nil {
+			profiles = append(profiles, *profile)
+		}
+	}
+	return profiles, nil
+}
+
+func (r *memoryProfileRepo) UpdateProfile(userID uuid.UUID, userUpdates, profileUpdates map[string]interface{}) error {
+	user, profile := r.users.users[userID], r.profiles[userID]
+	if user == nil || profile == nil {
+		return gorm.ErrRecordNotFound
+	}
+	if username, ok := userUpdates["username"]; ok {
+		user.Username = username.(string)
+	}
+	if bio, ok := profileUpdates["bio"]; ok {
+		profile.Bio = bio.(string)
+	}
+	return nil
+}
+
+// memoryBlockRepo keeps blocks in memory
+type memoryBlockRepo struct {
+	blocks map[[2]uuid.UUID]bool
+}
+
+var _ repositories.BlockRepository = (*memoryBlockRepo)(nil)
+
+func newMemoryBlockRepo() *memoryBlockRepo {
+	return &memoryBlockRepo{blocks: map[[2]uuid.UUID]bool{}}
+}
+
+func (r *memoryBlockRepo) BlockUser(blockerID, blockedID uuid.UUID) error {
+	r.blocks[[2]uuid.UUID{blockerID, blockedID}] = true
+	return nil
+}
+
+func (r *memoryBlockRepo) UnblockUser(blockerID, blockedID uuid.UUID) (bool, error) {
+	key := [2]uuid.UUID{blockerID, blockedID}
+	removed := r.blocks[key]
+	delete(r.blocks, key)
+	return removed, nil
+}
+
+func (r *memoryBlockRepo) GetBlockedUsers(blockerID uuid.UUID) ([]models.Block, error) {
+	blocks := []models.Block{}
+	for key := range r.blocks {
+		if key[0] == blockerID {
+			blocks = append(blocks, models.Block{BlockerID: key[0], BlockedID: key[1]})
+		}
+	}
+	return blocks, nil
+}
+
+func (r *memoryBlockRepo) IsBlocked(a, b uuid.UUID) (bool, error) {
+	return r.blocks[[2]uuid.UUID{a, b}] || r.blocks[[2]uuid.UUID{b, a}], nil
+}
+
+// authenticateAs stands in for the JWT middleware and signs every request in as the user
+func authenticateAs(userID uuid.UUID) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("userID", userID.String())
+		c.Next()
+	}
+}
+
+// serve sends a request with an optional JSON body to the router and returns the recorded response
+func serve(router http.Handler, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// memoryPremiumRepo keeps packages in memory; users never hold a subscription
+type memoryPremiumRepo struct {
+	packages map[uuid.UUID]*models.PremiumPackage
+}
+
+var _ repositories.PremiumRepository = (*memoryPremiumRepo)(nil)
+
+func newMemoryPremiumRepo() *memoryPremiumRepo {
+	return &memoryPremiumRepo{packages: map[uuid.UUID]*models.PremiumPackage{}}
+}
+
+func (r *memoryPremiumRepo) RegisterPremium(*models.UserPremium, *models.PremiumPackage) error {
+	return nil
+}
+
+func (r *memoryPremiumRepo) IsUserPremium(uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (r *memoryPremiumRepo) GetUserPremium(uuid.UUID) (*models.UserPremium, error) {
+	return nil, nil
+}
+
+func (r *memoryPremiumRepo) GetActiveSubscriptions(uuid.UUID) ([]models.UserPremium, error) {
+	return nil, nil
+}
+
+func (r *memoryPremiumRepo) GetLatestSubscription(uuid.UUID) (*models.UserPremium, error) {
+	return nil, nil
+}
+
+func (r *memoryPremiumRepo) ExpireSubscriptions(time.Time) ([]models.UserPremium, error) {
+	return nil, nil
+}
+
+func (r *memoryPremiumRepo) ClaimExpiringSubscriptions(time.Time, time.Time) ([]models.UserPremium, error) {
+	return nil, nil
+}
+
+func (r *memoryPremiumRepo) GetActiveEntitlements(uuid.UUID) ([]models.PackageEntitlement, error) {
+	return nil, nil
+}
+
+func (r *memoryPremiumRepo) GetPremiumPackages() ([]models.PremiumPackage, error) {
+	packages := []models.PremiumPackage{}
+	for _, pkg := range r.packages {
+		packages = append(packages, *pkg)
+	}
+	return packages, nil
+}
+
+func (r *memoryPremiumRepo) GetPremiumPackageByID(packageID uuid.UUID) (*models.PremiumPackage, error) {
+	pkg, ok := r.packages[packageID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *pkg
+	return &copied, nil
+}
+
+func (r *memoryPremiumRepo) CreatePremiumPackage(pkg *models.PremiumPackage) error {
+	pkg.ID = uuid.New()
+	r.packages[pkg.ID] = pkg
+	return nil
+}
+
+func (r *memoryPremiumRepo) UpdatePremiumPackage(pkg *models.PremiumPackage) error {
+	r.packages[pkg.ID] = pkg
+	return nil
+}
+
+func (r *memoryPremiumRepo) DeletePremiumPackage(packageID uuid.UUID) error {
+	delete(r.packages, packageID)
+	return nil
+}
+
+// memoryRoleRepo grants permissions to users directly, without going through roles
+type memoryRoleRepo struct {
+	permissions map[uuid.UUID][]string
+}
+
+var _ repositories.RoleRepository = (*memoryRoleRepo)(nil)
+
+func (r *memoryRoleRepo) GetRoles() ([]models.Role, error) {
+	return []models.Role{}, nil
+}
+
+func (r *memoryRoleRepo) GetRoleByName(string) (*models.Role, error) {
+	return nil, nil
+}
+
+func (r *memoryRoleRepo) GetUserRoleNames(uuid.UUID) ([]string, error) {
+	return []string{}, nil
+}
+
+func (r *memoryRoleRepo) UserHasPermission(userID uuid.UUID, permission string) (bool, error) {
+	return slices.Contains(r.permissions[userID], permission), nil
+}
+
+func (r *memoryRoleRepo) AssignRole(*models.UserRole) error {
+	return nil
+}
+
+func (r *memoryRoleRepo) RemoveRole(uuid.UUID, uuid.UUID) error {
+	return nil
+}
+
+func (r *memoryRoleRepo) RemoveRoleKeepingLast(uuid.UUID, uuid.UUID) error {
+	return nil
+}
+
+func (r *memoryRoleRepo) CountUsersWithRole(uuid.UUID) (int64, error) {
+	return 0, nil
+}
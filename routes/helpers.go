@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUserID extracts the authenticated user's ID set by middleware.JWTAuth.
+// On failure it writes a 401 response and returns false.
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	// Extract user ID from JWT claims
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return uuid.Nil, false
+	}
+
+	// Convert userID string to uuid.UUID
+	str, _ := userIDStr.(string)
+	userID, err := uuid.Parse(str)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"datingApp/tokens"
+)
+
+// RegisterJWKSRoutes publishes the public token verification keys for other services
+func RegisterJWKSRoutes(router *gin.Engine, keys *tokens.KeySet) {
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, keys.JWKS())
+	})
+}
@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"datingApp/models"
+	"datingApp/services"
+)
+
+func RegisterLikeRoutes(router *gin.Engine, likeService *services.LikeService, authMiddleware gin.HandlerFunc) {
+	likeGroup := router.Group("/likes")
+	likeGroup.Use(authMiddleware)
+	{
+		// Users who liked the caller and are waiting for an answer
+		likeGroup.GET("/received", func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+			if err != nil || limit < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+				return
+			}
+
+			likes, err := likeService.GetLikesReceived(userID, c.Query("cursor"), limit)
+			if errors.Is(err, models.ErrInvalidCursor) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch likes"})
+				return
+			}
+
+			c.JSON(http.StatusOK, likes)
+		})
+	}
+}
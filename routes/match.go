@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/services"
+)
+
+func RegisterMatchRoutes(router *gin.Engine, matchService *services.MatchService, authMiddleware gin.HandlerFunc) {
+	matchGroup := router.Group("/matches")
+	matchGroup.Use(authMiddleware)
+	{
+		// List the caller's mutual matches
+		matchGroup.GET("", func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			matches, err := matchService.GetMatches(userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch matches"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"matches": matches})
+		})
+
+		// Get a single match of the caller
+		matchGroup.GET("/:matchID", func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			matchID, err := uuid.Parse(c.Param("matchID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID format"})
+				return
+			}
+
+			match, err := matchService.GetMatch(userID, matchID)
+			if errors.Is(err, services.ErrMatchNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch match"})
+				return
+			}
+
+			c.JSON(http.StatusOK, match)
+		})
+
+		// Unmatch, ending the match for both users
+		matchGroup.DELETE("/:matchID", func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			matchID, err := uuid.Parse(c.Param("matchID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID format"})
+				return
+			}
+
+			err = matchService.Unmatch(userID, matchID)
+			if errors.Is(err, services.ErrMatchNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unmatch"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"message": "Unmatched successfully"})
+		})
+	}
+}
@@ -1,18 +1,23 @@
 package routes
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 
+	"datingApp/middleware"
 	"datingApp/models"
 	"datingApp/services"
 )
 
-func RegisterPremiumRoutes(r *gin.Engine, premiumService *services.PremiumService) {
+func RegisterPremiumRoutes(r *gin.Engine, premiumService *services.PremiumService, authMiddleware gin.HandlerFunc,
+	permissions middleware.PermissionChecker) {
 	premium := r.Group("/premium")
+	canManagePackages := middleware.RequirePermission(permissions, models.PermissionManagePackages)
+	canViewStatus := middleware.RequirePermission(permissions, models.PermissionViewPremiumStatus)
 	{
 		// Get all premium packages
 		premium.GET("/packages", func(c *gin.Context) {
@@ -45,7 +50,7 @@ func RegisterPremiumRoutes(r *gin.Engine, premiumService *services.PremiumServic
 		})
 
 		// Create new premium package (admin only)
-		premium.POST("/packages", func(c *gin.Context) {
+		premium.POST("/packages", authMiddleware, canManagePackages, func(c *gin.Context) {
 			var req models.PremiumPackageRequest // Assuming this is defined in `models`
 
 			if err := c.ShouldBindJSON(&req); err != nil {
@@ -59,7 +64,12 @@ func RegisterPremiumRoutes(r *gin.Engine, premiumService *services.PremiumServic
 				return
 			}
 
-			err = premiumService.CreatePremiumPackage(req.Name, req.Description, price)
+			err = premiumService.CreatePremiumPackage(req.Name, req.Description, price, req.DurationMonths,
+				toEntitlements(req.Entitlements))
+			if errors.Is(err, services.ErrInvalidPackage) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create premium package"})
 				return
@@ -68,8 +78,13 @@ func RegisterPremiumRoutes(r *gin.Engine, premiumService *services.PremiumServic
 			c.JSON(http.StatusCreated, gin.H{"message": "Premium package created successfully"})
 		})
 
-		// Purchase premium package
-		premium.POST("/purchase", func(c *gin.Context) {
+		// Purchase premium package for the caller
+		premium.POST("/purchase", authMiddleware, func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
 			var req models.PurchaseRequest // Assuming this is defined in `models`
 
 			if err := c.ShouldBindJSON(&req); err != nil {
@@ -77,45 +92,84 @@ func RegisterPremiumRoutes(r *gin.Engine, premiumService *services.PremiumServic
 				return
 			}
 
-			userID, err := uuid.Parse(req.UserID)
+			packageID, err := uuid.Parse(req.PackageID)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid package ID format"})
 				return
 			}
 
-			packageID, err := uuid.Parse(req.PackageID)
+			subscription, err := premiumService.RegisterPremium(userID, packageID)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid package ID format"})
+				respondPurchaseError(c, err)
 				return
 			}
 
-			if err := premiumService.RegisterPremium(userID, packageID); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusOK, gin.H{
+				"message":      "Premium package purchased successfully",
+				"subscription": models.NewSubscriptionResponse(subscription),
+			})
+		})
+
+		// Renew the caller's most recent premium package
+		premium.POST("/renew", authMiddleware, func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{"message": "Premium package purchased successfully"})
+			subscription, err := premiumService.RenewPremium(userID)
+			if errors.Is(err, services.ErrNothingToRenew) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				respondPurchaseError(c, err)
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{
+				"message":      "Premium subscription renewed successfully",
+				"subscription": models.NewSubscriptionResponse(subscription),
+			})
 		})
 
-		// Check premium status
-		premium.GET("/status/:userID", func(c *gin.Context) {
-			userID, err := uuid.Parse(c.Param("userID"))
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		// Get the caller's effective entitlements
+		premium.GET("/entitlements", authMiddleware, func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
 				return
 			}
 
-			isPremium, err := premiumService.IsUserPremium(userID)
+			entitlements, err := premiumService.GetEntitlements(userID)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check premium status"})
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch entitlements"})
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{"is_premium": isPremium})
+			c.JSON(http.StatusOK, gin.H{"entitlements": entitlements})
+		})
+
+		// Check the caller's premium status
+		premium.GET("/status", authMiddleware, func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+			respondPremiumStatus(c, premiumService, userID)
+		})
+
+		// Check any user's premium status (admin only)
+		premium.GET("/status/:userID", authMiddleware, canViewStatus, func(c *gin.Context) {
+			userID, err := uuid.Parse(c.Param("userID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+				return
+			}
+			respondPremiumStatus(c, premiumService, userID)
 		})
 
 		// Update premium package (admin only)
-		premium.PUT("/packages/:packageID", func(c *gin.Context) {
+		premium.PUT("/packages/:packageID", authMiddleware, canManagePackages, func(c *gin.Context) {
 			packageID, err := uuid.Parse(c.Param("packageID"))
 			if err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid package ID format"})
@@ -136,13 +190,24 @@ func RegisterPremiumRoutes(r *gin.Engine, premiumService *services.PremiumServic
 			}
 
 			pkg := &models.PremiumPackage{
-				ID:          packageID,
-				PackageName: req.Name,
-				Description: req.Description,
-				Price:       price,
+				ID:             packageID,
+				PackageName:    req.Name,
+				Description:    req.Description,
+				Price:          price,
+				DurationMonths: req.DurationMonths,
+				Entitlements:   toEntitlements(req.Entitlements),
 			}
 
-			if err := premiumService.UpdatePremiumPackage(pkg); err != nil {
+			err = premiumService.UpdatePremiumPackage(pkg)
+			if errors.Is(err, services.ErrInvalidPackage) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrPackageNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Package not found"})
+				return
+			}
+			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update premium package"})
 				return
 			}
@@ -151,14 +216,19 @@ func RegisterPremiumRoutes(r *gin.Engine, premiumService *services.PremiumServic
 		})
 
 		// Delete premium package (admin only)
-		premium.DELETE("/packages/:packageID", func(c *gin.Context) {
+		premium.DELETE("/packages/:packageID", authMiddleware, canManagePackages, func(c *gin.Context) {
 			packageID, err := uuid.Parse(c.Param("packageID"))
 			if err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid package ID format"})
 				return
 			}
 
-			if err := premiumService.DeletePremiumPackage(packageID); err != nil {
+			err = premiumService.DeletePremiumPackage(packageID)
+			if errors.Is(err, services.ErrPackageNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Package not found"})
+				return
+			}
+			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete premium package"})
 				return
 			}
@@ -167,3 +237,45 @@ func RegisterPremiumRoutes(r *gin.Engine, premiumService *services.PremiumServic
 		})
 	}
 }
+
+func toEntitlements(reqs []models.EntitlementRequest) []models.PackageEntitlement {
+	entitlements := make([]models.PackageEntitlement, 0, len(reqs))
+	for _, req := range reqs {
+		entitlements = append(entitlements, models.PackageEntitlement{
+			Feature: req.Feature,
+			Limit:   req.Limit,
+		})
+	}
+	return entitlements
+}
+
+// respondPurchaseError maps a failed purchase or renewal to its status code. Unexpected
+// errors get a generic message so database details never reach the client.
+func respondPurchaseError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrPackageNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrLifetimeSubscription) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purchase premium package"})
+}
+
+func respondPremiumStatus(c *gin.Context, premiumService *services.PremiumService, userID uuid.UUID) {
+	subscription, err := premiumService.GetUserPremiumDetails(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check premium status"})
+		return
+	}
+
+	if subscription == nil {
+		c.JSON(http.StatusOK, gin.H{"is_premium": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"is_premium":   true,
+		"subscription": models.NewSubscriptionResponse(subscription),
+	})
+}
@@ -0,0 +1,93 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/models"
+	"datingApp/services"
+)
+
+func RegisterProfileRoutes(router *gin.Engine, profileService *services.ProfileService, authMiddleware gin.HandlerFunc) {
+	profileGroup := router.Group("/profile")
+	profileGroup.Use(authMiddleware)
+	{
+		// Get the caller's own profile
+		profileGroup.GET("/me", func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			profile, err := profileService.GetMyProfile(userID)
+			if errors.Is(err, services.ErrProfileNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+				return
+			}
+
+			c.JSON(http.StatusOK, profile)
+		})
+
+		// Update the fields present in the request body
+		profileGroup.PATCH("/me", func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			var req models.UpdateProfileRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			profile, err := profileService.UpdateProfile(userID, req)
+			if errors.Is(err, services.ErrUsernameTaken) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrProfileNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+				return
+			}
+
+			c.JSON(http.StatusOK, profile)
+		})
+
+		// Get another user's public profile
+		profileGroup.GET("/:userID", func(c *gin.Context) {
+			viewerID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+			userID, err := uuid.Parse(c.Param("userID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+				return
+			}
+
+			profile, err := profileService.GetPublicProfile(viewerID, userID)
+			if errors.Is(err, services.ErrProfileNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+				return
+			}
+
+			c.JSON(http.StatusOK, profile)
+		})
+	}
+}
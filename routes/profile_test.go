@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/models"
+	"datingApp/services"
+)
+
+func TestProfileRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	me := &models.User{ID: uuid.New(), Username: "myself", Email: "me@example.com"}
+	other := &models.User{ID: uuid.New(), Username: "other"}
+	blocked := &models.User{ID: uuid.New(), Username: "blocked"}
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		wantStatus   int
+		wantUsername string
+		wantBio      string
+	}{
+		{"own profile", http.MethodGet, "/profile/me", "", http.StatusOK, "myself", "my bio"},
+		{"update bio only", http.MethodPatch, "/profile/me", `{"bio":"new bio"}`, http.StatusOK, "myself", "new bio"},
+		{"change username", http.MethodPatch, "/profile/me", `{"username":"renamed"}`, http.StatusOK, "renamed", "my bio"},
+		{"keep own username", http.MethodPatch, "/profile/me", `{"username":"myself"}`, http.StatusOK, "myself", "my bio"},
+		{"username taken", http.MethodPatch, "/profile/me", `{"username":"other"}`, http.StatusConflict, "", ""},
+		{"username too short", http.MethodPatch, "/profile/me", `{"username":"ab"}`, http.StatusBadRequest, "", ""},
+		{"invalid time zone", http.MethodPatch, "/profile/me", `{"timeZone":"Mars/Olympus"}`, http.StatusBadRequest, "", ""},
+		{"public profile", http.MethodGet, "/profile/" + other.ID.String(), "", http.StatusOK, "other", "their bio"},
+		{"blocked profile", http.MethodGet, "/profile/" + blocked.ID.String(), "", http.StatusNotFound, "", ""},
+		{"unknown profile", http.MethodGet, "/profile/" + uuid.NewString(), "", http.StatusNotFound, "", ""},
+		{"invalid user ID", http.MethodGet, "/profile/not-a-uuid", "", http.StatusBadRequest, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newMemoryUserRepo(&models.User{ID: me.ID, Username: me.Username, Email: me.Email}, other, blocked)
+			profiles := &memoryProfileRepo{users: users, profiles: map[uuid.UUID]*models.Profile{
+				me.ID:      {UserID: me.ID, Bio: "my bio"},
+				other.ID:   {UserID: other.ID, Bio: "their bio"},
+				blocked.ID: {UserID: blocked.ID, Bio: "hidden bio"},
+			}}
+			blocks := newMemoryBlockRepo()
+			blocks.BlockUser(blocked.ID, me.ID)
+
+			router := gin.New()
+			RegisterProfileRoutes(router, services.NewProfileService(profiles, users, blocks), authenticateAs(me.ID))
+
+			w := serve(router, tt.method, tt.path, tt.body, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var profile models.PublicProfileResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &profile); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if profile.Username != tt.wantUsername || profile.Bio != tt.wantBio {
+				t.Errorf("profile = %q with bio %q, want %q with bio %q", profile.Username, profile.Bio, tt.wantUsername, tt.wantBio)
+			}
+		})
+	}
+}
+
+func TestOwnProfileIncludesPrivateFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	me := &models.User{ID: uuid.New(), Username: "me", Email: "me@example.com", TimeZone: "Europe/Paris"}
+	users := newMemoryUserRepo(me)
+	profiles := &memoryProfileRepo{users: users, profiles: map[uuid.UUID]*models.Profile{me.ID: {UserID: me.ID}}}
+
+	router := gin.New()
+	RegisterProfileRoutes(router, services.NewProfileService(profiles, users, newMemoryBlockRepo()), authenticateAs(me.ID))
+
+	for path, wantEmail := range map[string]bool{"/profile/me": true, "/profile/" + me.ID.String(): false} {
+		w := serve(router, http.MethodGet, path, "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, want 200: %s", path, w.Code, w.Body)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if _, ok := body["email"]; ok != wantEmail {
+			t.Errorf("GET %s exposes the email: %t, want %t", path, ok, wantEmail)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"datingApp/realtime"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Connections are authenticated with an access token rather than cookies, so a
+	// foreign page cannot open one on the user's behalf
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// RegisterRealtimeRoutes exposes the WebSocket that pushes matches, messages, typing
+// indicators and read receipts. Browsers cannot set headers on the handshake, so the
+// stream middleware also accepts the access token as the access_token query parameter.
+// The socket is closed when its session is revoked.
+func RegisterRealtimeRoutes(router *gin.Engine, hub *realtime.Hub, streamAuth gin.HandlerFunc) {
+	router.GET("/ws", streamAuth, func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+
+		// The upgrader replies with an error itself when the handshake is invalid
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			log.Printf("WebSocket upgrade failed: %v", err)
+			return
+		}
+		hub.Serve(conn, userID, c.MustGet("sessionID").(uuid.UUID))
+	})
+}
@@ -0,0 +1,164 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"datingApp/middleware"
+	"datingApp/models"
+	"datingApp/services"
+)
+
+func RegisterReportRoutes(router *gin.Engine, moderationService *services.ModerationService, authMiddleware gin.HandlerFunc,
+	permissions middleware.PermissionChecker) {
+	// Report another user to the moderators
+	router.POST("/reports", authMiddleware, func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+
+		var req models.ReportRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		report, err := moderationService.ReportUser(userID, req)
+		if errors.Is(err, services.ErrCannotReportSelf) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		if errors.Is(err, services.ErrReportExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit report"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"report_id": report.ReportID, "status": report.Status})
+	})
+
+	moderation := router.Group("/admin")
+	moderation.Use(authMiddleware, middleware.RequirePermission(permissions, models.PermissionModerateReports))
+	{
+		// The moderation queue, oldest report first
+		moderation.GET("/reports", func(c *gin.Context) {
+			limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+			if err != nil || limit < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+				return
+			}
+
+			status := c.DefaultQuery("status", models.ReportStatusPending)
+			reports, err := moderationService.GetReports(status, c.Query("cursor"), limit)
+			if errors.Is(err, services.ErrInvalidReportStatus) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+				return
+			}
+			if errors.Is(err, models.ErrInvalidCursor) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+				return
+			}
+
+			c.JSON(http.StatusOK, reports)
+		})
+
+		// Take a pending report from the queue
+		moderation.POST("/reports/:reportID/claim", func(c *gin.Context) {
+			moderatorID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			reportID, err := uuid.Parse(c.Param("reportID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID format"})
+				return
+			}
+
+			report, err := moderationService.ClaimReport(moderatorID, reportID)
+			if errors.Is(err, services.ErrReportNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+				return
+			}
+			if errors.Is(err, services.ErrReportClaimed) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claim report"})
+				return
+			}
+
+			c.JSON(http.StatusOK, report)
+		})
+
+		// Close a claimed report with a decision
+		moderation.POST("/reports/:reportID/resolve", func(c *gin.Context) {
+			moderatorID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			reportID, err := uuid.Parse(c.Param("reportID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID format"})
+				return
+			}
+
+			var req models.ResolveReportRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			decision, err := moderationService.ResolveReport(moderatorID, reportID, req)
+			if errors.Is(err, services.ErrReportNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+				return
+			}
+			if errors.Is(err, services.ErrReportNotClaimed) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve report"})
+				return
+			}
+
+			c.JSON(http.StatusOK, decision)
+		})
+
+		// The moderation history of a user
+		moderation.GET("/users/:userID/moderation-decisions", func(c *gin.Context) {
+			userID, err := uuid.Parse(c.Param("userID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+				return
+			}
+
+			decisions, err := moderationService.GetDecisions(userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moderation decisions"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"decisions": decisions})
+		})
+	}
+}
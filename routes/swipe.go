@@ -1,33 +1,54 @@
 package routes
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
-	"datingApp/middleware"
 	"datingApp/models"
 	"datingApp/services"
 )
 
-func RegisterSwipeRoutes(router *gin.Engine, swipeService *services.SwipeService, jwtSecret string) {
+// RegisterSwipeRoutes registers the swipe routes. Swipe actions honour an optional
+// Idempotency-Key header through the idempotency middleware.
+func RegisterSwipeRoutes(router *gin.Engine, swipeService *services.SwipeService, authMiddleware gin.HandlerFunc,
+	idempotency gin.HandlerFunc) {
 	swipeGroup := router.Group("/swipe")
 	// Apply JWTAuth middleware
-	swipeGroup.Use(middleware.JWTAuth(jwtSecret))
+	swipeGroup.Use(authMiddleware)
 	{
-		swipeGroup.POST("/right", func(c *gin.Context) {
-			// Extract user ID from JWT claims
-			userIDStr, exists := c.Get("userID")
-			if !exists {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		swipeGroup.POST("/right", idempotency, func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
 				return
 			}
 
-			// Convert userID string to uuid.UUID
-			userID, err := uuid.Parse(userIDStr.(string))
+			var req models.SwipeRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+				return
+			}
+
+			match, err := swipeService.SwipeRight(userID, req.TargetUserID)
 			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+				respondSwipeError(c, err)
+				return
+			}
+
+			if match != nil {
+				c.JSON(http.StatusOK, gin.H{"message": "It's a match!", "matched": true, "match_id": match.ID})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "Swipe right recorded", "matched": false})
+		})
+
+		// Super like, shown prominently to the target and limited by its own daily allowance
+		swipeGroup.POST("/super", idempotency, func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
 				return
 			}
 
@@ -37,67 +58,170 @@ func RegisterSwipeRoutes(router *gin.Engine, swipeService *services.SwipeService
 				return
 			}
 
-			err = swipeService.SwipeRight(userID, req.ProfileID)
+			match, err := swipeService.SwipeSuper(userID, req.TargetUserID)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				respondSwipeError(c, err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{"message": "Swipe right recorded"})
+			if match != nil {
+				c.JSON(http.StatusOK, gin.H{"message": "It's a match!", "matched": true, "match_id": match.ID})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "Super like recorded", "matched": false})
 		})
 
-		swipeGroup.POST("/left", func(c *gin.Context) {
-			// Extract user ID from JWT claims
-			userIDStr, exists := c.Get("userID")
-			if !exists {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		swipeGroup.POST("/left", idempotency, func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
 				return
 			}
 
-			// Convert userID string to uuid.UUID
-			userID, err := uuid.Parse(userIDStr.(string))
+			var req models.SwipeRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+				return
+			}
+
+			err := swipeService.SwipeLeft(userID, req.TargetUserID)
 			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+				respondSwipeError(c, err)
 				return
 			}
 
-			var req models.SwipeRequest
+			c.JSON(http.StatusOK, gin.H{"message": "Swipe left recorded"})
+		})
+
+		// Take back the most recent swipe
+		swipeGroup.POST("/undo", idempotency, func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			swipe, err := swipeService.UndoLastSwipe(userID)
+			if errors.Is(err, services.ErrRewindNotAllowed) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrNothingToUndo) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrUndoLimitReached) {
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to undo swipe"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{
+				"message":        "Swipe undone",
+				"target_user_id": swipe.TargetUserID,
+				"action":         swipe.SwipeType,
+			})
+		})
+
+		// Change an existing swipe into a like or a pass
+		swipeGroup.PUT("/:targetUserID", idempotency, func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			targetUserID, err := uuid.Parse(c.Param("targetUserID"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+				return
+			}
+
+			var req models.ChangeSwipeRequest
 			if err := c.ShouldBindJSON(&req); err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 				return
 			}
 
-			err = swipeService.SwipeLeft(userID, req.ProfileID)
+			match, err := swipeService.ChangeSwipe(userID, targetUserID, req.Action)
+			if errors.Is(err, services.ErrSwipeNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrSwipeMatched) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				respondSwipeError(c, err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{"message": "Swipe left recorded"})
+			if match != nil {
+				c.JSON(http.StatusOK, gin.H{"message": "It's a match!", "action": req.Action, "matched": true, "match_id": match.ID})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "Swipe updated", "action": req.Action, "matched": false})
 		})
 
-		swipeGroup.GET("/matches", func(c *gin.Context) {
-			// Extract user ID from JWT claims
-			userIDStr, exists := c.Get("userID")
-			if !exists {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		// Report the caller's daily swipe usage
+		swipeGroup.GET("/quota", func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
 				return
 			}
 
-			// Convert userID string to uuid.UUID
-			userID, err := uuid.Parse(userIDStr.(string))
+			quota, err := swipeService.GetQuota(userID)
 			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch swipe quota"})
 				return
 			}
 
-			matches, err := swipeService.GetPotentialMatches(userID)
+			c.JSON(http.StatusOK, quota)
+		})
+
+		// Profiles the user can still swipe on; actual matches live under /matches
+		swipeGroup.GET("/candidates", func(c *gin.Context) {
+			userID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+
+			limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+			if err != nil || limit < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+				return
+			}
+
+			page, err := swipeService.GetPotentialMatches(userID, c.Query("cursor"), limit)
+			if errors.Is(err, models.ErrInvalidCursor) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+				return
+			}
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch candidates"})
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{"matches": matches})
+			c.JSON(http.StatusOK, page)
 		})
 	}
 }
+
+// respondSwipeError maps a failed swipe to its status code. Unexpected errors get a
+// generic message so database details never reach the client.
+func respondSwipeError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrCannotSwipeSelf) || errors.Is(err, services.ErrTargetNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrSwipeExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrQuotaExceeded) || errors.Is(err, services.ErrNoSuperLikesLeft) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record swipe"})
+}
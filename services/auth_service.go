@@ -1,24 +1,45 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 
+	"datingApp/events"
 	"datingApp/models"
 	"datingApp/repositories"
+	"datingApp/tokens"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
 )
 
 type AuthService struct {
-	UserRepo  repositories.UserRepository
-	SecretKey string
+	UserRepo    repositories.UserRepository
+	SessionRepo repositories.SessionRepository
+	RoleRepo    repositories.RoleRepository
+	Keys        *tokens.KeySet
+	Events      events.Publisher
 }
 
-func NewAuthService(userRepo repositories.UserRepository, secretKey string) *AuthService {
-	return &AuthService{UserRepo: userRepo, SecretKey: secretKey}
+func NewAuthService(userRepo repositories.UserRepository, sessionRepo repositories.SessionRepository,
+	roleRepo repositories.RoleRepository, keys *tokens.KeySet, publisher events.Publisher) *AuthService {
+	return &AuthService{UserRepo: userRepo, SessionRepo: sessionRepo, RoleRepo: roleRepo, Keys: keys, Events: publisher}
 }
 
 func (s *AuthService) SignUp(req models.SignUpRequest) (*models.SignUpResponse, error) {
@@ -27,17 +48,32 @@ func (s *AuthService) SignUp(req models.SignUpRequest) (*models.SignUpResponse,
 		return nil, errors.New("email already in use")
 	}
 
+	existingUser, err = s.UserRepo.GetUserByUsername(req.Username)
+	if err == nil && existingUser != nil {
+		return nil, ErrUsernameTaken
+	}
+
 	hashedPassword, err := hashPassword(req.Password)
 	if err != nil {
 		return nil, errors.New("failed to hash password")
 	}
 
 	user := &models.User{
-		Email:        req.Email,
-		PasswordHash: hashedPassword,
+		Email:         req.Email,
+		PasswordHash:  hashedPassword,
+		Username:      req.Username,
+		ProfilePicURL: req.ProfilePicURL,
+		TimeZone:      req.TimeZone,
+	}
+	if user.TimeZone == "" {
+		user.TimeZone = models.DefaultTimeZone
+	}
+	profile := &models.Profile{
+		Bio:       req.Bio,
+		Interests: models.StringList(req.Interests),
 	}
 
-	err = s.UserRepo.CreateUser(user)
+	err = s.UserRepo.CreateUserWithProfile(user, profile)
 	if err != nil {
 		return nil, err
 	}
@@ -56,35 +92,146 @@ func (s *AuthService) Login(email, password string) (*models.LoginResponse, erro
 		return nil, errors.New("invalid credentials")
 	}
 
+	// Start a new session with its first refresh token
+	refreshToken, stored, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	session := &models.Session{UserID: user.ID}
+	if err := s.SessionRepo.CreateSession(session, stored); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, session, refreshToken, stored)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token.
+// Presenting a refresh token that was already exchanged revokes the whole session,
+// since either the client or an attacker is replaying a stolen token.
+func (s *AuthService) Refresh(refreshToken string) (*models.LoginResponse, error) {
+	current, err := s.SessionRepo.GetRefreshToken(hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || current.Session.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if current.UsedAt != nil {
+		return nil, s.revokeReusedSession(current.Session.UserID, current.SessionID)
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Another request may have consumed the token since it was read
+	consumed, err := s.SessionRepo.MarkRefreshTokenUsed(current.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, s.revokeReusedSession(current.Session.UserID, current.SessionID)
+	}
+
+	user, err := s.UserRepo.GetUserByID(current.Session.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	rotated, stored, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	stored.SessionID = current.SessionID
+	if err := s.SessionRepo.CreateRefreshToken(stored); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, &current.Session, rotated, stored)
+}
+
+// Logout revokes the user's session, invalidating its access and refresh tokens and
+// closing its open streams
+func (s *AuthService) Logout(userID, sessionID uuid.UUID) error {
+	if err := s.SessionRepo.RevokeSession(sessionID); err != nil {
+		return err
+	}
+	publishSessionRevoked(s.Events, userID, &sessionID)
+	return nil
+}
+
+// IsSessionActive reports whether the session exists and has not been revoked
+func (s *AuthService) IsSessionActive(sessionID uuid.UUID) (bool, error) {
+	session, err := s.SessionRepo.GetSessionByID(sessionID)
+	if err != nil {
+		return false, err
+	}
+	return session != nil && session.RevokedAt == nil, nil
+}
+
+func (s *AuthService) revokeReusedSession(userID, sessionID uuid.UUID) error {
+	if err := s.SessionRepo.RevokeSession(sessionID); err != nil {
+		return err
+	}
+	publishSessionRevoked(s.Events, userID, &sessionID)
+	return ErrRefreshTokenReused
+}
+
+func (s *AuthService) issueTokens(user *models.User, session *models.Session, refreshToken string,
+	stored *models.RefreshToken) (*models.LoginResponse, error) {
+	expiresAt := time.Now().Add(accessTokenTTL)
+
+	roles, err := s.RoleRepo.GetUserRoleNames(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate JWT token
-	token, err := s.generateToken(user)
+	token, err := s.generateToken(user, roles, session.ID, expiresAt)
 	if err != nil {
 		return nil, err
 	}
 
-	// Return user ID and token in a LoginResponse struct
+	// Return user ID and tokens in a LoginResponse struct
 	return &models.LoginResponse{
-		UserID: user.ID,
-		Token:  token,
+		UserID:                user.ID,
+		Token:                 token,
+		TokenExpiresAt:        expiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: stored.ExpiresAt,
 	}, nil
 }
 
-func (s *AuthService) generateToken(user *models.User) (string, error) {
-	// Create the JWT claims, which includes the username and expiration time
+func (s *AuthService) generateToken(user *models.User, roles []string, sessionID uuid.UUID, expiresAt time.Time) (string, error) {
+	// Create the JWT claims, which includes the user, their roles, the session and expiration time
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(), // Set token expiration (e.g., 72 hours)
+		"roles":   roles,
+		"sid":     sessionID,
+		"exp":     expiresAt.Unix(),
 	}
 
-	// Create token with claims and sign it with your secret key
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.SecretKey))
-	if err != nil {
-		return "", err
+	// Sign the token with the active key, which is named in the "kid" header
+	return s.Keys.Sign(claims)
+}
+
+// newRefreshToken returns a random refresh token and the record to store for it
+func newRefreshToken() (string, *models.RefreshToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
 	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, &models.RefreshToken{
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}, nil
+}
 
-	return tokenString, nil
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 func hashPassword(password string) (string, error) {
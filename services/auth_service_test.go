@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"datingApp/events"
+	"datingApp/models"
+	"datingApp/tokens"
+)
+
+// newTestAuthService returns an auth service signing with a throwaway key and a
+// user who can log in with the password "secret"
+func newTestAuthService(t *testing.T, sessions *fakeSessionRepo, publisher events.Publisher) (*AuthService, *models.User) {
+	t.Helper()
+
+	// The minimum cost keeps the tests fast; checking the password does not depend on it
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword: %v", err)
+	}
+	user := &models.User{ID: uuid.New(), Email: "user@example.com", PasswordHash: string(hash)}
+	key, err := tokens.GenerateHMACKey("test")
+	if err != nil {
+		t.Fatalf("GenerateHMACKey: %v", err)
+	}
+	keys, err := tokens.NewKeySet("test", key)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	return NewAuthService(newFakeUserRepo(user), sessions, newFakeRoleRepo(), keys, publisher), user
+}
+
+func TestRefreshRotatesTheToken(t *testing.T) {
+	sessions := newFakeSessionRepo()
+	service, user := newTestAuthService(t, sessions, nil)
+
+	login, err := service.Login(user.Email, "secret")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	refreshed, err := service.Refresh(login.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Error("Refresh returned the same refresh token")
+	}
+	if refreshed.UserID != user.ID || refreshed.Token == "" {
+		t.Errorf("Refresh issued tokens for %v, want %v with an access token", refreshed.UserID, user.ID)
+	}
+
+	old := sessions.tokens[hashToken(login.RefreshToken)]
+	rotated := sessions.tokens[hashToken(refreshed.RefreshToken)]
+	if old.UsedAt == nil {
+		t.Error("the exchanged refresh token was not marked used")
+	}
+	if rotated == nil || rotated.SessionID != old.SessionID {
+		t.Fatal("the rotated refresh token does not belong to the original session")
+	}
+
+	// The rotated token can be exchanged in turn
+	if _, err := service.Refresh(refreshed.RefreshToken); err != nil {
+		t.Errorf("Refresh with the rotated token: %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesTheSession(t *testing.T) {
+	sessions := newFakeSessionRepo()
+	publisher := &fakePublisher{}
+	service, user := newTestAuthService(t, sessions, publisher)
+
+	login, err := service.Login(user.Email, "secret")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	refreshed, err := service.Refresh(login.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// Replaying the exchanged token betrays a stolen token
+	if _, err := service.Refresh(login.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("replayed refresh token: err = %v, want %v", err, ErrRefreshTokenReused)
+	}
+	sessionID := sessions.tokens[hashToken(login.RefreshToken)].SessionID
+	if active, _ := service.IsSessionActive(sessionID); active {
+		t.Error("the session is still active after refresh token reuse")
+	}
+	if revoked := publisher.recipients(events.TypeSessionRevoked); len(revoked) != 1 || revoked[0] != user.ID {
+		t.Errorf("session revocation was published to %v, want only %v", revoked, user.ID)
+	}
+
+	// Every token of the revoked session is dead, including the legitimate rotated one
+	if _, err := service.Refresh(refreshed.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("rotated token after revocation: err = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestRefreshRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(token *models.RefreshToken, session *models.Session)
+	}{
+		{"expired", func(token *models.RefreshToken, _ *models.Session) {
+			token.ExpiresAt = time.Now().Add(-time.Minute)
+		}},
+		{"logged out", func(_ *models.RefreshToken, session *models.Session) {
+			revokedAt := time.Now()
+			session.RevokedAt = &revokedAt
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := newFakeSessionRepo()
+			service, user := newTestAuthService(t, sessions, nil)
+			login, err := service.Login(user.Email, "secret")
+			if err != nil {
+				t.Fatalf("Login: %v", err)
+			}
+			token := sessions.tokens[hashToken(login.RefreshToken)]
+			tt.tamper(token, sessions.sessions[token.SessionID])
+
+			if _, err := service.Refresh(login.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("Refresh: err = %v, want %v", err, ErrInvalidRefreshToken)
+			}
+		})
+	}
+
+	service, _ := newTestAuthService(t, newFakeSessionRepo(), nil)
+	if _, err := service.Refresh("unknown"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Refresh with an unknown token: err = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestConcurrentRefreshesOfOneToken(t *testing.T) {
+	sessions := newFakeSessionRepo()
+	service, user := newTestAuthService(t, sessions, nil)
+	login, err := service.Login(user.Email, "secret")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	const attempts = 8
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = service.Refresh(login.RefreshToken)
+		}()
+	}
+	wg.Wait()
+
+	// One refresh wins. The others either detect the reuse and revoke the session or
+	// arrive after the revocation, and the session ends up revoked either way.
+	succeeded, reused := 0, 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrRefreshTokenReused):
+			reused++
+		case !errors.Is(err, ErrInvalidRefreshToken):
+			t.Errorf("losing refresh: unexpected error %v", err)
+		}
+	}
+	if succeeded != 1 || reused == 0 {
+		t.Errorf("%d refreshes succeeded and %d detected reuse, want 1 and at least 1", succeeded, reused)
+	}
+	sessionID := sessions.tokens[hashToken(login.RefreshToken)].SessionID
+	if active, _ := service.IsSessionActive(sessionID); active {
+		t.Error("the session is still active after the racing refreshes")
+	}
+}
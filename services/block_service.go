@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+var (
+	ErrCannotBlockSelf = errors.New("cannot block yourself")
+	ErrBlockNotFound   = errors.New("user is not blocked")
+)
+
+type BlockService struct {
+	BlockRepo repositories.BlockRepository
+	UserRepo  repositories.UserRepository
+}
+
+func NewBlockService(blockRepo repositories.BlockRepository, userRepo repositories.UserRepository) *BlockService {
+	return &BlockService{BlockRepo: blockRepo, UserRepo: userRepo}
+}
+
+// BlockUser hides the two users from each other and ends their match, if any
+func (s *BlockService) BlockUser(userID, blockedID uuid.UUID) error {
+	if userID == blockedID {
+		return ErrCannotBlockSelf
+	}
+	if _, err := s.UserRepo.GetUserByID(blockedID); err != nil {
+		return ErrUserNotFound
+	}
+	return s.BlockRepo.BlockUser(userID, blockedID)
+}
+
+// UnblockUser lifts a block made by the user. The users can find each other again,
+// but a match ended by the block is not restored.
+func (s *BlockService) UnblockUser(userID, blockedID uuid.UUID) error {
+	removed, err := s.BlockRepo.UnblockUser(userID, blockedID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrBlockNotFound
+	}
+	return nil
+}
+
+// GetBlockedUsers lists the users the user blocked, most recent first
+func (s *BlockService) GetBlockedUsers(userID uuid.UUID) ([]models.BlockResponse, error) {
+	blocks, err := s.BlockRepo.GetBlockedUsers(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]models.BlockResponse, 0, len(blocks))
+	for _, block := range blocks {
+		resp = append(resp, models.BlockResponse{UserID: block.BlockedID, BlockedAt: block.CreatedAt})
+	}
+	return resp, nil
+}
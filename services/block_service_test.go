@@ -0,0 +1,55 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"datingApp/models"
+)
+
+func TestBlockService(t *testing.T) {
+	alice, bob := &models.User{ID: uuid.New()}, &models.User{ID: uuid.New()}
+	blocks := newFakeBlockRepo()
+	service := NewBlockService(blocks, newFakeUserRepo(alice, bob))
+
+	if err := service.BlockUser(alice.ID, alice.ID); !errors.Is(err, ErrCannotBlockSelf) {
+		t.Errorf("blocking yourself: error = %v, want %v", err, ErrCannotBlockSelf)
+	}
+	if err := service.BlockUser(alice.ID, uuid.New()); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("blocking an unknown user: error = %v, want %v", err, ErrUserNotFound)
+	}
+	if err := service.UnblockUser(alice.ID, bob.ID); !errors.Is(err, ErrBlockNotFound) {
+		t.Errorf("unblocking before blocking: error = %v, want %v", err, ErrBlockNotFound)
+	}
+
+	if err := service.BlockUser(alice.ID, bob.ID); err != nil {
+		t.Fatalf("BlockUser: %v", err)
+	}
+	blocked, err := service.GetBlockedUsers(alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocked) != 1 || blocked[0].UserID != bob.ID {
+		t.Errorf("alice blocked %+v, want bob", blocked)
+	}
+	// Only the blocker lists the block, but it works both ways
+	if blocked, _ := service.GetBlockedUsers(bob.ID); len(blocked) != 0 {
+		t.Errorf("bob blocked %+v, want nobody", blocked)
+	}
+	if isBlocked, _ := blocks.IsBlocked(bob.ID, alice.ID); !isBlocked {
+		t.Error("the block does not apply to bob")
+	}
+	// Only the blocker can lift it
+	if err := service.UnblockUser(bob.ID, alice.ID); !errors.Is(err, ErrBlockNotFound) {
+		t.Errorf("unblocking a block made by the other user: error = %v, want %v", err, ErrBlockNotFound)
+	}
+
+	if err := service.UnblockUser(alice.ID, bob.ID); err != nil {
+		t.Fatalf("UnblockUser: %v", err)
+	}
+	if isBlocked, _ := blocks.IsBlocked(alice.ID, bob.ID); isBlocked {
+		t.Error("the block survived unblocking")
+	}
+}
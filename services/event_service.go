@@ -0,0 +1,70 @@
+package services
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"datingApp/events"
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+const (
+	// EventLogRetention is how long delivered events can be replayed to a resuming client
+	EventLogRetention = 24 * time.Hour
+	// ReplayPageSize is how many missed events are read from the log at a time
+	ReplayPageSize = 500
+)
+
+// EventService logs events in the user's event log before handing them to the bus,
+// so clients that lost their stream can resume it from the last event they received
+type EventService struct {
+	EventRepo repositories.EventRepository
+	Bus       events.Publisher
+}
+
+func NewEventService(eventRepo repositories.EventRepository, bus events.Publisher) *EventService {
+	return &EventService{EventRepo: eventRepo, Bus: bus}
+}
+
+// Publish logs the event, assigning its ID, and publishes it. A user's events are
+// published in ID order. Ephemeral events are published without being logged.
+func (s *EventService) Publish(event events.Event) error {
+	if event.Ephemeral() {
+		return s.Bus.Publish(event)
+	}
+
+	userEvent := &models.UserEvent{
+		UserID:    event.UserID,
+		Type:      event.Type,
+		Data:      models.JSON(event.Data),
+		CreatedAt: event.CreatedAt,
+	}
+	return s.EventRepo.AppendUserEvent(userEvent, func() error {
+		event.ID = userEvent.ID
+		return s.Bus.Publish(event)
+	})
+}
+
+// GetEventsAfter returns up to ReplayPageSize logged events of the user that follow
+// the given event ID, oldest first
+func (s *EventService) GetEventsAfter(userID uuid.UUID, afterID int64) ([]events.Event, error) {
+	userEvents, err := s.EventRepo.GetUserEventsAfter(userID, afterID, ReplayPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	missed := make([]events.Event, 0, len(userEvents))
+	for _, e := range userEvents {
+		missed = append(missed, events.Event{
+			ID:        e.ID,
+			Type:      e.Type,
+			UserID:    e.UserID,
+			Data:      json.RawMessage(e.Data),
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	return missed, nil
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"log"
+
+	"github.com/google/uuid"
+
+	"datingApp/events"
+)
+
+// publishEvent notifies the user of an event. Delivery is best effort: a failure is
+// logged and never fails the request that caused the event.
+func publishEvent(publisher events.Publisher, userID uuid.UUID, eventType string, data interface{}) {
+	if publisher == nil {
+		return
+	}
+	event, err := events.New(userID, eventType, data)
+	if err == nil {
+		err = publisher.Publish(event)
+	}
+	if err != nil {
+		log.Printf("Failed to publish %s event to user %s: %v", eventType, userID, err)
+	}
+}
+
+// publishSessionRevoked closes the streams still open for the revoked session, or for
+// every session of the user when sessionID is nil, on whichever node serves them
+func publishSessionRevoked(publisher events.Publisher, userID uuid.UUID, sessionID *uuid.UUID) {
+	publishEvent(publisher, userID, events.TypeSessionRevoked, events.SessionRevoked{SessionID: sessionID})
+}
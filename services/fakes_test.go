@@ -0,0 +1,873 @@
+package services
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"datingApp/events"
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+// fakeUserRepo keeps users in memory
+type fakeUserRepo struct {
+	users map[uuid.UUID]*models.User
+}
+
+func newFakeUserRepo(users ...*models.User) *fakeUserRepo {
+	r := &fakeUserRepo{users: map[uuid.UUID]*models.User{}}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *fakeUserRepo) GetUserByID(userID uuid.UUID) (*models.User, error) {
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepo) GetCandidates(uuid.UUID, *time.Time, *models.Cursor, int) ([]models.Candidate, error) {
+	return nil, nil
+}
+
+func (r *fakeUserRepo) GetUserByEmail(email string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) GetUserByUsername(username string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) CreateUser(user *models.User) error {
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *fakeUserRepo) CreateUserWithProfile(user *models.User, profile *models.Profile) error {
+	if err := r.CreateUser(user); err != nil {
+		return err
+	}
+	profile.UserID = user.ID
+	return nil
+}
+
+func (r *fakeUserRepo) SetVerified(userID uuid.UUID, verified bool) error {
+	if user, ok := r.users[userID]; ok {
+		user.IsVerified = verified
+	}
+	return nil
+}
+
+// fakePremiumRepo keeps packages and subscriptions in memory. It stacks purchases
+// like the real repository, without the locking.
+type fakePremiumRepo struct {
+	packages      map[uuid.UUID]*models.PremiumPackage
+	subscriptions []*models.UserPremium
+}
+
+var _ repositories.PremiumRepository = (*fakePremiumRepo)(nil)
+
+func newFakePremiumRepo(packages ...*models.PremiumPackage) *fakePremiumRepo {
+	r := &fakePremiumRepo{packages: map[uuid.UUID]*models.PremiumPackage{}}
+	for _, pkg := range packages {
+		if pkg.ID == uuid.Nil {
+			pkg.ID = uuid.New()
+		}
+		r.packages[pkg.ID] = pkg
+	}
+	return r
+}
+
+func (r *fakePremiumRepo) RegisterPremium(userPremium *models.UserPremium, pkg *models.PremiumPackage) error {
+	active, _ := r.GetActiveSubscriptions(userPremium.UserID)
+	startDate := userPremium.PurchaseDate
+	for _, sub := range active {
+		if sub.ExpiresAt == nil {
+			return repositories.ErrLifetimeSubscription
+		}
+		if sub.ExpiresAt.After(startDate) {
+			startDate = *sub.ExpiresAt
+		}
+	}
+
+	userPremium.ID = uuid.New()
+	userPremium.StartDate = startDate
+	if !pkg.IsLifetime() {
+		expiresAt := startDate.AddDate(0, pkg.DurationMonths, 0)
+		userPremium.ExpiresAt = &expiresAt
+	}
+	copied := *userPremium
+	r.subscriptions = append(r.subscriptions, &copied)
+	return nil
+}
+
+func (r *fakePremiumRepo) IsUserPremium(userID uuid.UUID) (bool, error) {
+	sub, err := r.GetUserPremium(userID)
+	return sub != nil, err
+}
+
+func (r *fakePremiumRepo) GetUserPremium(userID uuid.UUID) (*models.UserPremium, error) {
+	now := time.Now()
+	for _, sub := range r.subscriptions {
+		if sub.UserID == userID && r.covers(sub, now) {
+			copied := *sub
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakePremiumRepo) GetActiveSubscriptions(userID uuid.UUID) ([]models.UserPremium, error) {
+	now := time.Now()
+	var active []models.UserPremium
+	for _, sub := range r.subscriptions {
+		if sub.UserID == userID && sub.Status == models.SubscriptionStatusActive &&
+			(sub.ExpiresAt == nil || sub.ExpiresAt.After(now)) {
+			active = append(active, *sub)
+		}
+	}
+	return active, nil
+}
+
+func (r *fakePremiumRepo) GetLatestSubscription(userID uuid.UUID) (*models.UserPremium, error) {
+	var latest *models.UserPremium
+	for _, sub := range r.subscriptions {
+		if sub.UserID == userID && (latest == nil || sub.PurchaseDate.After(latest.PurchaseDate)) {
+			latest = sub
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	copied := *latest
+	return &copied, nil
+}
+
+func (r *fakePremiumRepo) ExpireSubscriptions(now time.Time) ([]models.UserPremium, error) {
+	var expired []models.UserPremium
+	for _, sub := range r.subscriptions {
+		if sub.Status == models.SubscriptionStatusActive && sub.ExpiresAt != nil && !sub.ExpiresAt.After(now) {
+			sub.Status = models.SubscriptionStatusExpired
+			expired = append(expired, *sub)
+		}
+	}
+	return expired, nil
+}
+
+func (r *fakePremiumRepo) ClaimExpiringSubscriptions(now, before time.Time) ([]models.UserPremium, error) {
+	var expiring []models.UserPremium
+	for _, sub := range r.subscriptions {
+		if sub.Status != models.SubscriptionStatusActive || sub.ExpiryNotifiedAt != nil || sub.ExpiresAt == nil ||
+			!sub.ExpiresAt.After(now) || sub.ExpiresAt.After(before) || r.followed(sub) {
+			continue
+		}
+		notifiedAt := now
+		sub.ExpiryNotifiedAt = &notifiedAt
+		expiring = append(expiring, *sub)
+	}
+	return expiring, nil
+}
+
+// followed reports whether another active subscription of the user outlasts sub
+func (r *fakePremiumRepo) followed(sub *models.UserPremium) bool {
+	for _, later := range r.subscriptions {
+		if later.UserID == sub.UserID && later.ID != sub.ID && later.Status == models.SubscriptionStatusActive &&
+			(later.ExpiresAt == nil || later.ExpiresAt.After(*sub.ExpiresAt)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakePremiumRepo) GetActiveEntitlements(userID uuid.UUID) ([]models.PackageEntitlement, error) {
+	now := time.Now()
+	var entitlements []models.PackageEntitlement
+	for _, sub := range r.subscriptions {
+		if sub.UserID != userID || !r.covers(sub, now) {
+			continue
+		}
+		if pkg, ok := r.packages[sub.PackageID]; ok {
+			entitlements = append(entitlements, pkg.Entitlements...)
+		}
+	}
+	return entitlements, nil
+}
+
+func (r *fakePremiumRepo) GetPremiumPackages() ([]models.PremiumPackage, error) {
+	packages := make([]models.PremiumPackage, 0, len(r.packages))
+	for _, pkg := range r.packages {
+		packages = append(packages, *pkg)
+	}
+	return packages, nil
+}
+
+func (r *fakePremiumRepo) GetPremiumPackageByID(packageID uuid.UUID) (*models.PremiumPackage, error) {
+	pkg, ok := r.packages[packageID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *pkg
+	return &copied, nil
+}
+
+func (r *fakePremiumRepo) CreatePremiumPackage(pkg *models.PremiumPackage) error {
+	pkg.ID = uuid.New()
+	r.packages[pkg.ID] = pkg
+	return nil
+}
+
+func (r *fakePremiumRepo) UpdatePremiumPackage(pkg *models.PremiumPackage) error {
+	r.packages[pkg.ID] = pkg
+	return nil
+}
+
+func (r *fakePremiumRepo) DeletePremiumPackage(packageID uuid.UUID) error {
+	delete(r.packages, packageID)
+	return nil
+}
+
+// covers reports whether the subscription grants its package at the given time
+func (r *fakePremiumRepo) covers(sub *models.UserPremium, now time.Time) bool {
+	return sub.Status == models.SubscriptionStatusActive && !sub.StartDate.After(now) &&
+		(sub.ExpiresAt == nil || sub.ExpiresAt.After(now))
+}
+
+// fakeRoleRepo keeps roles and grants in memory; it knows no permissions
+type fakeRoleRepo struct {
+	roles  map[string]*models.Role
+	grants map[uuid.UUID]map[uuid.UUID]bool
+}
+
+func newFakeRoleRepo(names ...string) *fakeRoleRepo {
+	r := &fakeRoleRepo{roles: map[string]*models.Role{}, grants: map[uuid.UUID]map[uuid.UUID]bool{}}
+	for _, name := range names {
+		r.roles[name] = &models.Role{ID: uuid.New(), Name: name}
+	}
+	return r
+}
+
+func (r *fakeRoleRepo) GetRoles() ([]models.Role, error) {
+	roles := []models.Role{}
+	for _, role := range r.roles {
+		roles = append(roles, *role)
+	}
+	return roles, nil
+}
+
+func (r *fakeRoleRepo) GetRoleByName(name string) (*models.Role, error) {
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, nil
+	}
+	copied := *role
+	return &copied, nil
+}
+
+func (r *fakeRoleRepo) GetUserRoleNames(userID uuid.UUID) ([]string, error) {
+	names := []string{}
+	for _, role := range r.roles {
+		if r.grants[role.ID][userID] {
+			names = append(names, role.Name)
+		}
+	}
+	return names, nil
+}
+
+func (r *fakeRoleRepo) UserHasPermission(uuid.UUID, string) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeRoleRepo) AssignRole(userRole *models.UserRole) error {
+	if r.grants[userRole.RoleID] == nil {
+		r.grants[userRole.RoleID] = map[uuid.UUID]bool{}
+	}
+	r.grants[userRole.RoleID][userRole.UserID] = true
+	return nil
+}
+
+func (r *fakeRoleRepo) RemoveRole(userID, roleID uuid.UUID) error {
+	delete(r.grants[roleID], userID)
+	return nil
+}
+
+func (r *fakeRoleRepo) RemoveRoleKeepingLast(userID, roleID uuid.UUID) error {
+	if r.grants[roleID][userID] && len(r.grants[roleID]) <= 1 {
+		return repositories.ErrLastRoleHolder
+	}
+	return r.RemoveRole(userID, roleID)
+}
+
+func (r *fakeRoleRepo) CountUsersWithRole(roleID uuid.UUID) (int64, error) {
+	return int64(len(r.grants[roleID])), nil
+}
+
+// fakeSwipeRepo keeps swipes, quotas and matches in memory and remembers every quota
+// charge it was handed
+type fakeSwipeRepo struct {
+	swipes  map[[2]uuid.UUID]*models.Swipe
+	quotas  map[uuid.UUID]map[string]*models.SwipeQuota
+	matches []*models.Match
+	charges []repositories.QuotaCharge
+}
+
+func newFakeSwipeRepo() *fakeSwipeRepo {
+	return &fakeSwipeRepo{
+		swipes: map[[2]uuid.UUID]*models.Swipe{},
+		quotas: map[uuid.UUID]map[string]*models.SwipeQuota{},
+	}
+}
+
+func (r *fakeSwipeRepo) GetSwipe(userID, targetUserID uuid.UUID) (*models.Swipe, error) {
+	swipe, ok := r.swipes[[2]uuid.UUID{userID, targetUserID}]
+	if !ok {
+		return nil, nil
+	}
+	copied := *swipe
+	return &copied, nil
+}
+
+func (r *fakeSwipeRepo) RecordSwipe(userID, targetUserID uuid.UUID, swipeType string, passRecycleBefore *time.Time,
+	charge repositories.QuotaCharge) (*models.Match, error) {
+	if swipe, ok := r.swipes[[2]uuid.UUID{userID, targetUserID}]; ok {
+		recycled := passRecycleBefore != nil && swipe.SwipeType == models.SwipeTypePass && swipe.SwipeDate.Before(*passRecycleBefore)
+		if !recycled {
+			return nil, repositories.ErrSwipeExists
+		}
+	}
+
+	quota := r.quota(userID, charge.Day)
+	used := &quota.Used
+	if charge.Super {
+		used = &quota.SuperUsed
+	}
+	if charge.Limit != nil && *used >= *charge.Limit {
+		if charge.Super {
+			return nil, repositories.ErrSuperLikesExhausted
+		}
+		return nil, repositories.ErrQuotaExceeded
+	}
+	*used++
+	r.charges = append(r.charges, charge)
+
+	now := time.Now()
+	r.swipes[[2]uuid.UUID{userID, targetUserID}] = &models.Swipe{
+		ID:           uuid.New(),
+		UserID:       userID,
+		TargetUserID: targetUserID,
+		SwipeType:    swipeType,
+		SwipeDate:    now,
+		CreatedAt:    now,
+	}
+	if swipeType == models.SwipeTypePass {
+		return nil, nil
+	}
+	return r.matchIfMutual(userID, targetUserID), nil
+}
+
+func (r *fakeSwipeRepo) ChangeSwipe(userID, targetUserID uuid.UUID, swipeType string) (*models.Match, error) {
+	swipe, ok := r.swipes[[2]uuid.UUID{userID, targetUserID}]
+	if !ok {
+		return nil, nil
+	}
+	swipe.SwipeType = swipeType
+	if swipeType == models.SwipeTypePass {
+		return nil, nil
+	}
+	return r.matchIfMutual(userID, targetUserID), nil
+}
+
+func (r *fakeSwipeRepo) GetQuota(userID uuid.UUID, day time.Time) (*models.SwipeQuota, error) {
+	copied := *r.quota(userID, day)
+	return &copied, nil
+}
+
+func (r *fakeSwipeRepo) UndoLastSwipe(userID uuid.UUID, swipedAfter, _ time.Time, _ int) (*models.Swipe, *models.Match, error) {
+	var last *models.Swipe
+	for key, swipe := range r.swipes {
+		if key[0] == userID && (last == nil || swipe.SwipeDate.After(last.SwipeDate)) {
+			last = swipe
+		}
+	}
+	if last == nil || !last.SwipeDate.After(swipedAfter) {
+		return nil, nil, repositories.ErrNothingToUndo
+	}
+	delete(r.swipes, [2]uuid.UUID{userID, last.TargetUserID})
+
+	var ended *models.Match
+	for _, match := range r.matches {
+		if match.IsActive() && match.OtherUserID(userID) == last.TargetUserID &&
+			(match.UserAID == userID || match.UserBID == userID) {
+			now := time.Now()
+			match.Status, match.EndedByID, match.EndedAt = models.MatchStatusUnmatched, &userID, &now
+			copied := *match
+			ended = &copied
+		}
+	}
+	return last, ended, nil
+}
+
+func (r *fakeSwipeRepo) CountLikesReceived(userID uuid.UUID) (int, error) {
+	likes, _ := r.GetLikesReceived(userID, nil, len(r.swipes))
+	return len(likes), nil
+}
+
+func (r *fakeSwipeRepo) GetLikesReceived(userID uuid.UUID, after *models.Cursor, limit int) ([]models.Swipe, error) {
+	likes := []models.Swipe{}
+	for key, swipe := range r.swipes {
+		if key[1] != userID || swipe.SwipeType == models.SwipeTypePass {
+			continue
+		}
+		if _, answered := r.swipes[[2]uuid.UUID{userID, key[0]}]; !answered {
+			likes = append(likes, *swipe)
+		}
+	}
+
+	// Newest first by (created_at, id), like the real query
+	newer := func(a, b models.Swipe) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID.String() > b.ID.String()
+	}
+	slices.SortFunc(likes, func(a, b models.Swipe) int {
+		if newer(a, b) {
+			return -1
+		}
+		return 1
+	})
+	if after != nil {
+		cursor := models.Swipe{ID: after.ID, CreatedAt: after.CreatedAt}
+		likes = slices.DeleteFunc(likes, func(swipe models.Swipe) bool { return !newer(cursor, swipe) })
+	}
+	return likes[:min(limit, len(likes))], nil
+}
+
+// quota returns the stored quota row of the user's day, creating it when missing
+func (r *fakeSwipeRepo) quota(userID uuid.UUID, day time.Time) *models.SwipeQuota {
+	if r.quotas[userID] == nil {
+		r.quotas[userID] = map[string]*models.SwipeQuota{}
+	}
+	key := day.Format(time.DateOnly)
+	if r.quotas[userID][key] == nil {
+		year, month, date := day.Date()
+		r.quotas[userID][key] = &models.SwipeQuota{UserID: userID, Day: time.Date(year, month, date, 0, 0, 0, 0, time.UTC)}
+	}
+	return r.quotas[userID][key]
+}
+
+// matchIfMutual creates the pair's match when the target liked the user back
+func (r *fakeSwipeRepo) matchIfMutual(userID, targetUserID uuid.UUID) *models.Match {
+	back, ok := r.swipes[[2]uuid.UUID{targetUserID, userID}]
+	if !ok || back.SwipeType == models.SwipeTypePass {
+		return nil
+	}
+	for _, match := range r.matches {
+		if (match.UserAID == userID && match.UserBID == targetUserID) || (match.UserAID == targetUserID && match.UserBID == userID) {
+			return nil
+		}
+	}
+	match := &models.Match{
+		ID:        uuid.New(),
+		UserAID:   userID,
+		UserBID:   targetUserID,
+		MatchedAt: time.Now(),
+		Status:    models.MatchStatusActive,
+	}
+	r.matches = append(r.matches, match)
+	return match
+}
+
+// fakeBlockRepo keeps blocks in memory
+type fakeBlockRepo struct {
+	blocks map[[2]uuid.UUID]bool
+}
+
+func newFakeBlockRepo() *fakeBlockRepo {
+	return &fakeBlockRepo{blocks: map[[2]uuid.UUID]bool{}}
+}
+
+func (r *fakeBlockRepo) BlockUser(blockerID, blockedID uuid.UUID) error {
+	r.blocks[[2]uuid.UUID{blockerID, blockedID}] = true
+	return nil
+}
+
+func (r *fakeBlockRepo) UnblockUser(blockerID, blockedID uuid.UUID) (bool, error) {
+	key := [2]uuid.UUID{blockerID, blockedID}
+	removed := r.blocks[key]
+	delete(r.blocks, key)
+	return removed, nil
+}
+
+func (r *fakeBlockRepo) GetBlockedUsers(blockerID uuid.UUID) ([]models.Block, error) {
+	blocks := []models.Block{}
+	for key := range r.blocks {
+		if key[0] == blockerID {
+			blocks = append(blocks, models.Block{BlockerID: key[0], BlockedID: key[1]})
+		}
+	}
+	return blocks, nil
+}
+
+func (r *fakeBlockRepo) IsBlocked(a, b uuid.UUID) (bool, error) {
+	return r.blocks[[2]uuid.UUID{a, b}] || r.blocks[[2]uuid.UUID{b, a}], nil
+}
+
+// fakeMatchRepo keeps matches in memory
+type fakeMatchRepo struct {
+	matches []*models.Match
+}
+
+func newFakeMatchRepo(matches ...*models.Match) *fakeMatchRepo {
+	return &fakeMatchRepo{matches: matches}
+}
+
+func (r *fakeMatchRepo) GetMatchesForUser(userID uuid.UUID) ([]models.Match, error) {
+	matches := []models.Match{}
+	for _, match := range r.matches {
+		if match.IsActive() && (match.UserAID == userID || match.UserBID == userID) {
+			matches = append(matches, *match)
+		}
+	}
+	return matches, nil
+}
+
+func (r *fakeMatchRepo) GetMatchByID(matchID uuid.UUID) (*models.Match, error) {
+	for _, match := range r.matches {
+		if match.ID == matchID {
+			copied := *match
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeMatchRepo) EndMatch(matchID, endedByID uuid.UUID, status string) (bool, error) {
+	for _, match := range r.matches {
+		if match.ID == matchID && match.IsActive() {
+			now := time.Now()
+			match.Status, match.EndedByID, match.EndedAt = status, &endedByID, &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakePublisher records the events published to it
+type fakePublisher struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (p *fakePublisher) Publish(event events.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+// recipients returns the users that received events of the given type, in order
+func (p *fakePublisher) recipients(eventType string) []uuid.UUID {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	users := []uuid.UUID{}
+	for _, event := range p.events {
+		if event.Type == eventType {
+			users = append(users, event.UserID)
+		}
+	}
+	return users
+}
+
+// fakeProfileRepo keeps profiles in memory and reads their users from a fakeUserRepo
+type fakeProfileRepo struct {
+	users    *fakeUserRepo
+	profiles map[uuid.UUID]*models.Profile
+}
+
+func newFakeProfileRepo(users *fakeUserRepo, profiles ...*models.Profile) *fakeProfileRepo {
+	r := &fakeProfileRepo{users: users, profiles: map[uuid.UUID]*models.Profile{}}
+	for _, profile := range profiles {
+		r.profiles[profile.UserID] = profile
+	}
+	return r
+}
+
+func (r *fakeProfileRepo) GetProfileByUserID(userID uuid.UUID) (*models.Profile, error) {
+	profile, ok := r.profiles[userID]
+	if !ok {
+		return nil, nil
+	}
+	user, err := r.users.GetUserByID(userID)
+	if err != nil {
+		return nil, nil
+	}
+	copied := *profile
+	copied.User = *user
+	return &copied, nil
+}
+
+func (r *fakeProfileRepo) GetProfilesByUserIDs(userIDs []uuid.UUID) ([]models.Profile, error) {
+	profiles := []models.Profile{}
+	for _, userID := range userIDs {
+		if profile, _ := r.GetProfileByUserID(userID); profile != nil {
+			profiles = append(profiles, *profile)
+		}
+	}
+	return profiles, nil
+}
+
+func (r *fakeProfileRepo) UpdateProfile(userID uuid.UUID, userUpdates, profileUpdates map[string]interface{}) error {
+	user, profile := r.users.users[userID], r.profiles[userID]
+	if user == nil || profile == nil {
+		return gorm.ErrRecordNotFound
+	}
+	for column, value := range userUpdates {
+		switch column {
+		case "username":
+			user.Username = value.(string)
+		case "profile_pic_url":
+			user.ProfilePicURL = value.(string)
+		case "time_zone":
+			user.TimeZone = value.(string)
+		}
+	}
+	for column, value := range profileUpdates {
+		switch column {
+		case "bio":
+			profile.Bio = value.(string)
+		case "interests":
+			profile.Interests = value.(models.StringList)
+		}
+	}
+	return nil
+}
+
+// fakeSessionRepo keeps sessions and refresh tokens in memory. It is safe for
+// concurrent use so racing refreshes can be tested.
+type fakeSessionRepo struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*models.Session
+	tokens   map[string]*models.RefreshToken
+}
+
+var _ repositories.SessionRepository = (*fakeSessionRepo)(nil)
+
+func newFakeSessionRepo() *fakeSessionRepo {
+	return &fakeSessionRepo{sessions: map[uuid.UUID]*models.Session{}, tokens: map[string]*models.RefreshToken{}}
+}
+
+func (r *fakeSessionRepo) CreateSession(session *models.Session, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session.ID = uuid.New()
+	copied := *session
+	r.sessions[session.ID] = &copied
+	token.SessionID = session.ID
+	return r.createRefreshToken(token)
+}
+
+func (r *fakeSessionRepo) GetSessionByID(sessionID uuid.UUID) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *fakeSessionRepo) RevokeSession(sessionID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[sessionID]; ok && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+	}
+	return nil
+}
+
+func (r *fakeSessionRepo) RevokeUserSessions(userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeSessionRepo) GetRefreshToken(tokenHash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	copied := *token
+	copied.Session = *r.sessions[token.SessionID]
+	return &copied, nil
+}
+
+func (r *fakeSessionRepo) MarkRefreshTokenUsed(tokenID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.ID == tokenID && token.UsedAt == nil {
+			now := time.Now()
+			token.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeSessionRepo) CreateRefreshToken(token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.createRefreshToken(token)
+}
+
+func (r *fakeSessionRepo) createRefreshToken(token *models.RefreshToken) error {
+	token.ID = uuid.New()
+	copied := *token
+	r.tokens[token.TokenHash] = &copied
+	return nil
+}
+
+// fakeConversationRepo keeps conversations, messages and read markers in memory.
+// Conversations share their match with the test so ending it closes them.
+type fakeConversationRepo struct {
+	conversations map[uuid.UUID]*models.Conversation
+	matches       map[uuid.UUID]*models.Match
+	messages      []*models.Message
+	reads         map[[2]uuid.UUID]time.Time
+}
+
+var _ repositories.ConversationRepository = (*fakeConversationRepo)(nil)
+
+func newFakeConversationRepo() *fakeConversationRepo {
+	return &fakeConversationRepo{
+		conversations: map[uuid.UUID]*models.Conversation{},
+		matches:       map[uuid.UUID]*models.Match{},
+		reads:         map[[2]uuid.UUID]time.Time{},
+	}
+}
+
+// open starts the conversation of a match
+func (r *fakeConversationRepo) open(match *models.Match) uuid.UUID {
+	conversation := &models.Conversation{ID: uuid.New(), MatchID: match.ID, CreatedAt: time.Now()}
+	r.conversations[conversation.ID] = conversation
+	r.matches[conversation.ID] = match
+	return conversation.ID
+}
+
+func (r *fakeConversationRepo) GetConversationsForUser(userID uuid.UUID) ([]models.Conversation, error) {
+	conversations := []models.Conversation{}
+	for id := range r.conversations {
+		if conversation, _ := r.GetConversationByID(id); conversation.Match.IsActive() &&
+			(conversation.Match.UserAID == userID || conversation.Match.UserBID == userID) {
+			conversations = append(conversations, *conversation)
+		}
+	}
+	return conversations, nil
+}
+
+func (r *fakeConversationRepo) GetConversationByID(conversationID uuid.UUID) (*models.Conversation, error) {
+	conversation, ok := r.conversations[conversationID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *conversation
+	copied.Match = *r.matches[conversationID]
+	return &copied, nil
+}
+
+func (r *fakeConversationRepo) CountUnread(userID uuid.UUID, conversationIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := map[uuid.UUID]int{}
+	for _, message := range r.messages {
+		readAt, read := r.reads[[2]uuid.UUID{message.ConversationID, userID}]
+		if slices.Contains(conversationIDs, message.ConversationID) && message.SenderID != userID &&
+			(!read || message.CreatedAt.After(readAt)) {
+			counts[message.ConversationID]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *fakeConversationRepo) CreateMessage(message *models.Message) error {
+	message.ID = uuid.New()
+	copied := *message
+	r.messages = append(r.messages, &copied)
+	r.conversations[message.ConversationID].LastMessageAt = &copied.CreatedAt
+	return r.MarkRead(message.ConversationID, message.SenderID, message.CreatedAt)
+}
+
+func (r *fakeConversationRepo) GetMessages(conversationID uuid.UUID, after *models.Cursor, limit int) ([]models.Message, error) {
+	messages := []models.Message{}
+	for i := len(r.messages) - 1; i >= 0; i-- {
+		message := r.messages[i]
+		if message.ConversationID != conversationID || message.DeletedAt.Valid {
+			continue
+		}
+		if after != nil && !message.CreatedAt.Before(after.CreatedAt) {
+			continue
+		}
+		messages = append(messages, *message)
+	}
+	return messages[:min(limit, len(messages))], nil
+}
+
+func (r *fakeConversationRepo) GetMessageByID(messageID uuid.UUID) (*models.Message, error) {
+	for _, message := range r.messages {
+		if message.ID == messageID && !message.DeletedAt.Valid {
+			copied := *message
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeConversationRepo) DeleteMessage(messageID uuid.UUID) error {
+	for _, message := range r.messages {
+		if message.ID == messageID {
+			message.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		}
+	}
+	return nil
+}
+
+func (r *fakeConversationRepo) MarkRead(conversationID, userID uuid.UUID, at time.Time) error {
+	key := [2]uuid.UUID{conversationID, userID}
+	if at.After(r.reads[key]) {
+		r.reads[key] = at
+	}
+	return nil
+}
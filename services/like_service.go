@@ -0,0 +1,87 @@
+package services
+
+import (
+	"github.com/google/uuid"
+
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+type LikeService struct {
+	SwipeRepo   repositories.SwipeRepository
+	ProfileRepo repositories.ProfileRepository
+	Premium     PremiumServiceInterface
+}
+
+func NewLikeService(swipeRepo repositories.SwipeRepository, profileRepo repositories.ProfileRepository,
+	premium PremiumServiceInterface) *LikeService {
+	return &LikeService{
+		SwipeRepo:   swipeRepo,
+		ProfileRepo: profileRepo,
+		Premium:     premium,
+	}
+}
+
+// GetLikesReceived reports the users who liked the caller and have not been swiped on
+// in return. Everyone sees how many there are; the profiles behind the likes are only
+// listed, newest first, for users with the see who liked you entitlement.
+func (s *LikeService) GetLikesReceived(userID uuid.UUID, cursor string, limit int) (*models.LikesReceivedResponse, error) {
+	after, err := models.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultCandidatePageSize
+	}
+	limit = min(limit, maxCandidatePageSize)
+
+	count, err := s.SwipeRepo.CountLikesReceived(userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &models.LikesReceivedResponse{Count: count}
+
+	unlocked, err := s.Premium.HasEntitlement(userID, models.FeatureSeeWhoLikedYou)
+	if err != nil || !unlocked {
+		return resp, err
+	}
+	resp.Unlocked = true
+
+	// Fetch one extra like to know whether another page follows
+	swipes, err := s.SwipeRepo.GetLikesReceived(userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(swipes) > limit {
+		swipes = swipes[:limit]
+		last := swipes[limit-1]
+		resp.NextCursor = models.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+
+	resp.Likes = make([]models.ReceivedLikeResponse, 0, len(swipes))
+	if len(swipes) == 0 {
+		return resp, nil
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(swipes))
+	for _, swipe := range swipes {
+		userIDs = append(userIDs, swipe.UserID)
+	}
+	profiles, err := s.ProfileRepo.GetProfilesByUserIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	byUserID := make(map[uuid.UUID]*models.Profile, len(profiles))
+	for i := range profiles {
+		byUserID[profiles[i].UserID] = &profiles[i]
+	}
+
+	for i := range swipes {
+		profile, ok := byUserID[swipes[i].UserID]
+		if !ok {
+			continue
+		}
+		resp.Likes = append(resp.Likes, models.NewReceivedLikeResponse(&swipes[i], profile))
+	}
+	return resp, nil
+}
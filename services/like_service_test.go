@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+func TestLikesInbox(t *testing.T) {
+	me := &models.User{ID: uuid.New()}
+	first := &models.User{ID: uuid.New(), Username: "first"}
+	second := &models.User{ID: uuid.New(), Username: "second"}
+	superLiker := &models.User{ID: uuid.New(), Username: "super"}
+	passer := &models.User{ID: uuid.New(), Username: "passer"}
+	answered := &models.User{ID: uuid.New(), Username: "answered"}
+	users := newFakeUserRepo(me, first, second, superLiker, passer, answered)
+	profiles := newFakeProfileRepo(users)
+	for _, user := range []*models.User{first, second, superLiker, passer, answered} {
+		profiles.profiles[user.ID] = &models.Profile{UserID: user.ID}
+	}
+
+	swipes := newFakeSwipeRepo()
+	likedAt := time.Now().Add(-time.Hour)
+	for i, like := range []struct {
+		from      *models.User
+		swipeType string
+	}{
+		{first, models.SwipeTypeLike},
+		{second, models.SwipeTypeLike},
+		{superLiker, models.SwipeTypeSuperLike},
+		{passer, models.SwipeTypePass},
+		{answered, models.SwipeTypeLike},
+	} {
+		if _, err := swipes.RecordSwipe(like.from.ID, me.ID, like.swipeType, nil, repositories.QuotaCharge{Day: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		swipes.swipes[[2]uuid.UUID{like.from.ID, me.ID}].CreatedAt = likedAt.Add(time.Duration(i) * time.Minute)
+	}
+	// Liking back or passing answers a like, so it leaves the inbox
+	if _, err := swipes.RecordSwipe(me.ID, answered.ID, models.SwipeTypePass, nil, repositories.QuotaCharge{Day: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	seeWhoLiked := &models.PremiumPackage{DurationMonths: 1,
+		Entitlements: []models.PackageEntitlement{{Feature: models.FeatureSeeWhoLikedYou}}}
+	premium := NewPremiumService(newFakePremiumRepo(seeWhoLiked), users, nil)
+	service := NewLikeService(swipes, profiles, premium)
+
+	// Without the entitlement only the number of likes is revealed
+	locked, err := service.GetLikesReceived(me.ID, "", 10)
+	if err != nil {
+		t.Fatalf("GetLikesReceived: %v", err)
+	}
+	if locked.Count != 3 || locked.Unlocked || len(locked.Likes) != 0 {
+		t.Errorf("locked inbox = count %d, unlocked %t, %d likes; want 3, false, 0", locked.Count, locked.Unlocked, len(locked.Likes))
+	}
+
+	if _, err := premium.RegisterPremium(me.ID, seeWhoLiked.ID); err != nil {
+		t.Fatalf("RegisterPremium: %v", err)
+	}
+	page, err := service.GetLikesReceived(me.ID, "", 2)
+	if err != nil {
+		t.Fatalf("GetLikesReceived: %v", err)
+	}
+	if page.Count != 3 || !page.Unlocked || page.NextCursor == "" {
+		t.Errorf("first page = count %d, unlocked %t, next cursor %q; want 3, true and a cursor", page.Count, page.Unlocked, page.NextCursor)
+	}
+	if got := likerNames(page.Likes); !slices.Equal(got, []string{"super", "second"}) {
+		t.Errorf("first page = %v, want the newest likes [super second]", got)
+	}
+	if len(page.Likes) > 0 && !page.Likes[0].SuperLike {
+		t.Error("the super like is not flagged")
+	}
+
+	last, err := service.GetLikesReceived(me.ID, page.NextCursor, 2)
+	if err != nil {
+		t.Fatalf("GetLikesReceived: %v", err)
+	}
+	if got := likerNames(last.Likes); !slices.Equal(got, []string{"first"}) || last.NextCursor != "" {
+		t.Errorf("last page = %v with next cursor %q, want [first] and no cursor", got, last.NextCursor)
+	}
+
+	if _, err := service.GetLikesReceived(me.ID, "not-a-cursor", 2); !errors.Is(err, models.ErrInvalidCursor) {
+		t.Errorf("invalid cursor: err = %v, want %v", err, models.ErrInvalidCursor)
+	}
+}
+
+func likerNames(likes []models.ReceivedLikeResponse) []string {
+	names := []string{}
+	for _, like := range likes {
+		names = append(names, like.Username)
+	}
+	return names
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+
+	"datingApp/events"
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+var ErrMatchNotFound = errors.New("match not found")
+
+type MatchService struct {
+	MatchRepo repositories.MatchRepository
+	Events    events.Publisher
+}
+
+func NewMatchService(matchRepo repositories.MatchRepository, publisher events.Publisher) *MatchService {
+	return &MatchService{MatchRepo: matchRepo, Events: publisher}
+}
+
+// GetMatches lists the user's matches from their point of view
+func (s *MatchService) GetMatches(userID uuid.UUID) ([]models.MatchResponse, error) {
+	matches, err := s.MatchRepo.GetMatchesForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]models.MatchResponse, 0, len(matches))
+	for i := range matches {
+		resp = append(resp, toMatchResponse(&matches[i], userID))
+	}
+	return resp, nil
+}
+
+// GetMatch returns a single active match, provided the user takes part in it
+func (s *MatchService) GetMatch(userID, matchID uuid.UUID) (*models.MatchResponse, error) {
+	match, err := s.getActiveMatch(userID, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := toMatchResponse(match, userID)
+	return &resp, nil
+}
+
+// Unmatch ends an active match on behalf of one of its users. The pair is never
+// shown to each other again, and the match no longer lists for either of them.
+func (s *MatchService) Unmatch(userID, matchID uuid.UUID) error {
+	match, err := s.getActiveMatch(userID, matchID)
+	if err != nil {
+		return err
+	}
+
+	ended, err := s.MatchRepo.EndMatch(match.ID, userID, models.MatchStatusUnmatched)
+	if err != nil {
+		return err
+	}
+	// The other user ended it first
+	if !ended {
+		return ErrMatchNotFound
+	}
+	publishMatchEnded(s.Events, match)
+	return nil
+}
+
+// getActiveMatch loads an active match the user takes part in
+func (s *MatchService) getActiveMatch(userID, matchID uuid.UUID) (*models.Match, error) {
+	match, err := s.MatchRepo.GetMatchByID(matchID)
+	if err != nil {
+		return nil, err
+	}
+	// Matches of other users and ended matches are reported as missing rather than forbidden
+	if match == nil || !match.IsActive() || (match.UserAID != userID && match.UserBID != userID) {
+		return nil, ErrMatchNotFound
+	}
+	return match, nil
+}
+
+// publishMatchEnded tells both users that their match ended
+func publishMatchEnded(publisher events.Publisher, match *models.Match) {
+	for _, userID := range []uuid.UUID{match.UserAID, match.UserBID} {
+		publishEvent(publisher, userID, events.TypeMatchEnded, toMatchResponse(match, userID))
+	}
+}
+
+func toMatchResponse(match *models.Match, userID uuid.UUID) models.MatchResponse {
+	return models.MatchResponse{
+		MatchID:   match.ID,
+		UserID:    match.OtherUserID(userID),
+		MatchedAt: match.MatchedAt,
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"datingApp/events"
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+func TestMutualLikesCreateMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		first     string
+		second    string
+		wantMatch bool
+	}{
+		{"like and like", models.SwipeTypeLike, models.SwipeTypeLike, true},
+		{"super like and like", models.SwipeTypeSuperLike, models.SwipeTypeLike, true},
+		{"like and super like", models.SwipeTypeLike, models.SwipeTypeSuperLike, true},
+		{"pass and like", models.SwipeTypePass, models.SwipeTypeLike, false},
+		{"like and pass", models.SwipeTypeLike, models.SwipeTypePass, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alice, bob := &models.User{ID: uuid.New()}, &models.User{ID: uuid.New()}
+			swipes, publisher := newFakeSwipeRepo(), &fakePublisher{}
+			service := newTestSwipeService(newFakeUserRepo(alice, bob), swipes, newFakeBlockRepo())
+			service.Events = publisher
+
+			if match, err := swipeAs(service, tt.first, alice.ID, bob.ID); err != nil || match != nil {
+				t.Fatalf("first swipe = %v, %v, want no match", match, err)
+			}
+			match, err := swipeAs(service, tt.second, bob.ID, alice.ID)
+			if err != nil {
+				t.Fatalf("second swipe: %v", err)
+			}
+			if (match != nil) != tt.wantMatch {
+				t.Fatalf("match = %v, want a match: %t", match, tt.wantMatch)
+			}
+
+			matched := publisher.recipients(events.TypeMatchCreated)
+			if tt.wantMatch && !equalUsers(matched, []uuid.UUID{match.UserAID, match.UserBID}) {
+				t.Errorf("match.created sent to %v, want both users", matched)
+			}
+			if !tt.wantMatch && len(matched) != 0 {
+				t.Errorf("match.created sent to %v without a match", matched)
+			}
+
+			// Each user sees the match from their own side
+			matches := NewMatchService(newFakeMatchRepo(swipes.matches...), nil)
+			for _, pair := range [][2]*models.User{{alice, bob}, {bob, alice}} {
+				list, err := matches.GetMatches(pair[0].ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !tt.wantMatch {
+					if len(list) != 0 {
+						t.Errorf("matches of %s = %+v, want none", pair[0].ID, list)
+					}
+					continue
+				}
+				if len(list) != 1 || list[0].UserID != pair[1].ID || list[0].MatchID != match.ID {
+					t.Errorf("matches of %s = %+v, want the match with %s", pair[0].ID, list, pair[1].ID)
+				}
+			}
+		})
+	}
+}
+
+// swipeAs swipes with the given action and returns the match it created, if any
+func swipeAs(service *SwipeService, swipeType string, userID, targetUserID uuid.UUID) (*models.Match, error) {
+	switch swipeType {
+	case models.SwipeTypeSuperLike:
+		return service.SwipeSuper(userID, targetUserID)
+	case models.SwipeTypePass:
+		return nil, service.SwipeLeft(userID, targetUserID)
+	default:
+		return service.SwipeRight(userID, targetUserID)
+	}
+}
+
+// endedConcurrently ends every match right before the caller's update lands, as when
+// the other user unmatches at the same moment
+type endedConcurrently struct {
+	*fakeMatchRepo
+}
+
+func (r endedConcurrently) EndMatch(matchID, _ uuid.UUID, status string) (bool, error) {
+	for _, match := range r.matches {
+		if match.ID == matchID {
+			match.Status = status
+		}
+	}
+	return false, nil
+}
+
+func TestUnmatch(t *testing.T) {
+	alice, bob, outsider := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name       string
+		status     string
+		caller     uuid.UUID
+		unknown    bool
+		concurrent bool
+		wantErr    error
+	}{
+		{"by the first user", models.MatchStatusActive, alice, false, false, nil},
+		{"by the second user", models.MatchStatusActive, bob, false, false, nil},
+		{"by someone else", models.MatchStatusActive, outsider, false, false, ErrMatchNotFound},
+		{"unknown match", models.MatchStatusActive, alice, true, false, ErrMatchNotFound},
+		{"already unmatched", models.MatchStatusUnmatched, alice, false, false, ErrMatchNotFound},
+		{"ended by a block", models.MatchStatusBlocked, alice, false, false, ErrMatchNotFound},
+		{"ended by the other user meanwhile", models.MatchStatusActive, alice, false, true, ErrMatchNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := &models.Match{ID: uuid.New(), UserAID: alice, UserBID: bob, MatchedAt: time.Now(), Status: tt.status}
+			var repo repositories.MatchRepository = newFakeMatchRepo(match)
+			if tt.concurrent {
+				repo = endedConcurrently{newFakeMatchRepo(match)}
+			}
+			publisher := &fakePublisher{}
+			service := NewMatchService(repo, publisher)
+
+			matchID := match.ID
+			if tt.unknown {
+				matchID = uuid.New()
+			}
+			err := service.Unmatch(tt.caller, matchID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unmatch: err = %v, want %v", err, tt.wantErr)
+			}
+
+			ended := publisher.recipients(events.TypeMatchEnded)
+			if tt.wantErr != nil {
+				if len(ended) != 0 {
+					t.Errorf("match.ended sent to %v after a failed unmatch", ended)
+				}
+				if !tt.concurrent && match.Status != tt.status {
+					t.Errorf("status = %q after a failed unmatch, want %q", match.Status, tt.status)
+				}
+				return
+			}
+
+			if match.Status != models.MatchStatusUnmatched || match.EndedByID == nil || *match.EndedByID != tt.caller {
+				t.Errorf("match = status %q ended by %v, want %q ended by %v", match.Status, match.EndedByID, models.MatchStatusUnmatched, tt.caller)
+			}
+			if !equalUsers(ended, []uuid.UUID{alice, bob}) {
+				t.Errorf("match.ended sent to %v, want both users", ended)
+			}
+			for _, userID := range []uuid.UUID{alice, bob} {
+				if list, _ := service.GetMatches(userID); len(list) != 0 {
+					t.Errorf("matches of %s after unmatching = %+v, want none", userID, list)
+				}
+				if _, err := service.GetMatch(userID, match.ID); !errors.Is(err, ErrMatchNotFound) {
+					t.Errorf("GetMatch after unmatching: err = %v, want %v", err, ErrMatchNotFound)
+				}
+			}
+			if err := service.Unmatch(bob, match.ID); !errors.Is(err, ErrMatchNotFound) {
+				t.Errorf("unmatching twice: err = %v, want %v", err, ErrMatchNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"datingApp/events"
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+const (
+	defaultMessagePageSize = 50
+	maxMessagePageSize     = 100
+)
+
+var (
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrMessageNotFound      = errors.New("message not found")
+)
+
+type MessageService struct {
+	ConversationRepo repositories.ConversationRepository
+	Events           events.Publisher
+}
+
+func NewMessageService(conversationRepo repositories.ConversationRepository, publisher events.Publisher) *MessageService {
+	return &MessageService{ConversationRepo: conversationRepo, Events: publisher}
+}
+
+// GetConversations lists the conversations of the user's active matches with their
+// unread counts, most recently active first
+func (s *MessageService) GetConversations(userID uuid.UUID) ([]models.ConversationResponse, error) {
+	conversations, err := s.ConversationRepo.GetConversationsForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]models.ConversationResponse, 0, len(conversations))
+	if len(conversations) == 0 {
+		return resp, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(conversations))
+	for _, conversation := range conversations {
+		ids = append(ids, conversation.ID)
+	}
+	unread, err := s.ConversationRepo.CountUnread(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, conversation := range conversations {
+		resp = append(resp, models.ConversationResponse{
+			ConversationID: conversation.ID,
+			MatchID:        conversation.MatchID,
+			UserID:         conversation.Match.OtherUserID(userID),
+			LastMessageAt:  conversation.LastMessageAt,
+			UnreadCount:    unread[conversation.ID],
+		})
+	}
+	return resp, nil
+}
+
+// GetMessages returns a page of the conversation's history, newest first
+func (s *MessageService) GetMessages(userID, conversationID uuid.UUID, cursor string, limit int) (*models.MessagePage, error) {
+	after, err := models.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultMessagePageSize
+	}
+	limit = min(limit, maxMessagePageSize)
+
+	if _, err := s.getConversation(userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra message to know whether another page follows
+	messages, err := s.ConversationRepo.GetMessages(conversationID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.MessagePage{Messages: make([]models.MessageResponse, 0, limit)}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		last := messages[limit-1]
+		page.NextCursor = models.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for i := range messages {
+		page.Messages = append(page.Messages, models.NewMessageResponse(&messages[i]))
+	}
+	return page, nil
+}
+
+// SendMessage posts a message to the conversation on behalf of one of its users
+func (s *MessageService) SendMessage(userID, conversationID uuid.UUID, body string) (*models.MessageResponse, error) {
+	conversation, err := s.getConversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &models.Message{
+		ConversationID: conversationID,
+		SenderID:       userID,
+		Body:           body,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.ConversationRepo.CreateMessage(message); err != nil {
+		return nil, err
+	}
+
+	resp := models.NewMessageResponse(message)
+
+	// The sender's other devices get the message too
+	publishEvent(s.Events, userID, events.TypeMessageCreated, resp)
+	publishEvent(s.Events, conversation.Match.OtherUserID(userID), events.TypeMessageCreated, resp)
+	return &resp, nil
+}
+
+// MarkRead marks every message received in the conversation so far as read and sends
+// the other user a read receipt
+func (s *MessageService) MarkRead(userID, conversationID uuid.UUID) error {
+	conversation, err := s.getConversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+
+	readAt := time.Now()
+	if err := s.ConversationRepo.MarkRead(conversationID, userID, readAt); err != nil {
+		return err
+	}
+
+	publishEvent(s.Events, conversation.Match.OtherUserID(userID), events.TypeMessageRead, models.ReadReceiptResponse{
+		ConversationID: conversationID,
+		UserID:         userID,
+		ReadAt:         readAt,
+	})
+	return nil
+}
+
+// Typing tells the other user of the conversation that the user is typing
+func (s *MessageService) Typing(userID, conversationID uuid.UUID) error {
+	conversation, err := s.getConversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+
+	publishEvent(s.Events, conversation.Match.OtherUserID(userID), events.TypeTyping, models.TypingResponse{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	return nil
+}
+
+// DeleteMessage hides one of the user's own messages from the conversation
+func (s *MessageService) DeleteMessage(userID, conversationID, messageID uuid.UUID) error {
+	if _, err := s.getConversation(userID, conversationID); err != nil {
+		return err
+	}
+
+	message, err := s.ConversationRepo.GetMessageByID(messageID)
+	if err != nil {
+		return err
+	}
+	// Messages of the other user are reported as missing rather than forbidden
+	if message == nil || message.ConversationID != conversationID || message.SenderID != userID {
+		return ErrMessageNotFound
+	}
+	return s.ConversationRepo.DeleteMessage(messageID)
+}
+
+// getConversation loads a conversation the user takes part in, provided its match is
+// still active; an ended match hides the conversation from both users
+func (s *MessageService) getConversation(userID, conversationID uuid.UUID) (*models.Conversation, error) {
+	conversation, err := s.ConversationRepo.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conversation == nil || !conversation.Match.IsActive() ||
+		(conversation.Match.UserAID != userID && conversation.Match.UserBID != userID) {
+		return nil, ErrConversationNotFound
+	}
+	return conversation, nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"datingApp/events"
+	"datingApp/models"
+)
+
+// newTestConversation opens the conversation of an active match between the two users
+func newTestConversation(conversations *fakeConversationRepo, a, b uuid.UUID) (*models.Match, uuid.UUID) {
+	match := &models.Match{ID: uuid.New(), UserAID: a, UserBID: b, MatchedAt: time.Now(), Status: models.MatchStatusActive}
+	return match, conversations.open(match)
+}
+
+func TestMessaging(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	conversations := newFakeConversationRepo()
+	_, conversationID := newTestConversation(conversations, alice, bob)
+	publisher := &fakePublisher{}
+	service := NewMessageService(conversations, publisher)
+
+	for _, send := range []struct {
+		from uuid.UUID
+		body string
+	}{{alice, "hi"}, {bob, "hello"}, {alice, "how are you?"}, {alice, "still there?"}} {
+		if _, err := service.SendMessage(send.from, conversationID, send.body); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+	}
+	// Every message reaches both users, the sender's other devices included
+	if created := publisher.recipients(events.TypeMessageCreated); len(created) != 8 {
+		t.Errorf("message.created sent %d times, want 8", len(created))
+	}
+
+	list, err := service.GetConversations(bob)
+	if err != nil {
+		t.Fatalf("GetConversations: %v", err)
+	}
+	if len(list) != 1 || list[0].UserID != alice || list[0].UnreadCount != 2 || list[0].LastMessageAt == nil {
+		t.Fatalf("bob's conversations = %+v, want one with alice and 2 unread", list)
+	}
+
+	if err := service.MarkRead(bob, conversationID); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	if receipts := publisher.recipients(events.TypeMessageRead); !slices.Equal(receipts, []uuid.UUID{alice}) {
+		t.Errorf("read receipt sent to %v, want only alice", receipts)
+	}
+	if list, _ := service.GetConversations(bob); len(list) != 1 || list[0].UnreadCount != 0 {
+		t.Errorf("bob's conversations after reading = %+v, want nothing unread", list)
+	}
+
+	if err := service.Typing(alice, conversationID); err != nil {
+		t.Fatalf("Typing: %v", err)
+	}
+	if typing := publisher.recipients(events.TypeTyping); !slices.Equal(typing, []uuid.UUID{bob}) {
+		t.Errorf("typing sent to %v, want only bob", typing)
+	}
+
+	// History pages newest first
+	page, err := service.GetMessages(bob, conversationID, "", 3)
+	if err != nil {
+		t.Fatalf("GetMessages: %v", err)
+	}
+	if got := messageBodies(page.Messages); !slices.Equal(got, []string{"still there?", "how are you?", "hello"}) || page.NextCursor == "" {
+		t.Errorf("first page = %v with cursor %q, want the 3 newest messages and a cursor", got, page.NextCursor)
+	}
+	page, err = service.GetMessages(bob, conversationID, page.NextCursor, 3)
+	if err != nil {
+		t.Fatalf("GetMessages: %v", err)
+	}
+	if got := messageBodies(page.Messages); !slices.Equal(got, []string{"hi"}) || page.NextCursor != "" {
+		t.Errorf("last page = %v with cursor %q, want the first message and no cursor", got, page.NextCursor)
+	}
+}
+
+func TestConversationAccess(t *testing.T) {
+	alice, bob, outsider := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		caller  uuid.UUID
+		status  string
+		unknown bool
+		wantErr error
+	}{
+		{"participant", alice, models.MatchStatusActive, false, nil},
+		{"outsider", outsider, models.MatchStatusActive, false, ErrConversationNotFound},
+		{"unknown conversation", alice, models.MatchStatusActive, true, ErrConversationNotFound},
+		{"after unmatching", alice, models.MatchStatusUnmatched, false, ErrConversationNotFound},
+		{"after a block", bob, models.MatchStatusBlocked, false, ErrConversationNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conversations := newFakeConversationRepo()
+			match, conversationID := newTestConversation(conversations, alice, bob)
+			publisher := &fakePublisher{}
+			service := NewMessageService(conversations, publisher)
+			sent, err := service.SendMessage(alice, conversationID, "before")
+			if err != nil {
+				t.Fatalf("SendMessage: %v", err)
+			}
+			match.Status = tt.status
+			if tt.unknown {
+				conversationID = uuid.New()
+			}
+			publisher.events = nil
+
+			operations := map[string]func() error{
+				"send": func() error {
+					_, err := service.SendMessage(tt.caller, conversationID, "hello")
+					return err
+				},
+				"history": func() error {
+					_, err := service.GetMessages(tt.caller, conversationID, "", 10)
+					return err
+				},
+				"read":   func() error { return service.MarkRead(tt.caller, conversationID) },
+				"typing": func() error { return service.Typing(tt.caller, conversationID) },
+				"delete": func() error { return service.DeleteMessage(tt.caller, conversationID, sent.MessageID) },
+			}
+			for name, operation := range operations {
+				if err := operation(); !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s: err = %v, want %v", name, err, tt.wantErr)
+				}
+			}
+			if tt.wantErr != nil && len(publisher.events) != 0 {
+				t.Errorf("%d events published to a closed conversation", len(publisher.events))
+			}
+
+			list, err := service.GetConversations(tt.caller)
+			if err != nil {
+				t.Fatalf("GetConversations: %v", err)
+			}
+			if wantListed := tt.wantErr == nil || tt.unknown; (len(list) == 1) != wantListed {
+				t.Errorf("conversations = %+v, want listed: %t", list, wantListed)
+			}
+		})
+	}
+}
+
+func TestDeleteMessage(t *testing.T) {
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	conversations := newFakeConversationRepo()
+	_, conversationID := newTestConversation(conversations, alice, bob)
+	_, otherConversationID := newTestConversation(conversations, alice, carol)
+	service := NewMessageService(conversations, nil)
+
+	mine, err := service.SendMessage(alice, conversationID, "oops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs, err := service.SendMessage(bob, conversationID, "hey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	elsewhere, err := service.SendMessage(alice, otherConversationID, "hi carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		messageID uuid.UUID
+		wantErr   error
+	}{
+		{"the other user's message", theirs.MessageID, ErrMessageNotFound},
+		{"message of another conversation", elsewhere.MessageID, ErrMessageNotFound},
+		{"unknown message", uuid.New(), ErrMessageNotFound},
+		{"own message", mine.MessageID, nil},
+		{"already deleted", mine.MessageID, ErrMessageNotFound},
+	}
+	for _, tt := range tests {
+		if err := service.DeleteMessage(alice, conversationID, tt.messageID); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	page, err := service.GetMessages(bob, conversationID, "", 10)
+	if err != nil {
+		t.Fatalf("GetMessages: %v", err)
+	}
+	if got := messageBodies(page.Messages); !slices.Equal(got, []string{"hey"}) {
+		t.Errorf("history after deleting = %v, want [hey]", got)
+	}
+}
+
+func messageBodies(messages []models.MessageResponse) []string {
+	bodies := []string{}
+	for _, message := range messages {
+		bodies = append(bodies, message.Body)
+	}
+	return bodies
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+const (
+	defaultReportPageSize = 20
+	maxReportPageSize     = 100
+)
+
+var (
+	ErrReportExists        = repositories.ErrReportExists
+	ErrReportNotClaimed    = repositories.ErrReportNotClaimed
+	ErrCannotReportSelf    = errors.New("cannot report yourself")
+	ErrReportNotFound      = errors.New("report not found")
+	ErrReportClaimed       = errors.New("report is already claimed or resolved")
+	ErrInvalidReportStatus = errors.New("invalid report status")
+)
+
+// ModerationConfig holds the tunable rules of the moderation queue
+type ModerationConfig struct {
+	// AutoHideThreshold is how many distinct users must have open reports about a user
+	// before their profile is hidden pending review; 0 disables hiding
+	AutoHideThreshold int
+}
+
+type ModerationService struct {
+	ReportRepo repositories.ReportRepository
+	UserRepo   repositories.UserRepository
+	Config     ModerationConfig
+}
+
+func NewModerationService(reportRepo repositories.ReportRepository, userRepo repositories.UserRepository,
+	config ModerationConfig) *ModerationService {
+	return &ModerationService{ReportRepo: reportRepo, UserRepo: userRepo, Config: config}
+}
+
+// ReportUser files a report about another user for moderators to review
+func (s *ModerationService) ReportUser(reporterID uuid.UUID, req models.ReportRequest) (*models.ReportResponse, error) {
+	if reporterID == req.ReportedUserID {
+		return nil, ErrCannotReportSelf
+	}
+	if _, err := s.UserRepo.GetUserByID(req.ReportedUserID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	report := &models.Report{
+		ReporterID: reporterID,
+		ReportedID: req.ReportedUserID,
+		Reason:     req.Reason,
+		Details:    req.Details,
+		Status:     models.ReportStatusPending,
+		CreatedAt:  time.Now(),
+	}
+	if _, err := s.ReportRepo.CreateReport(report, s.Config.AutoHideThreshold); err != nil {
+		return nil, err
+	}
+
+	resp := models.NewReportResponse(report)
+	return &resp, nil
+}
+
+// GetReports returns a page of the reports with the given status, oldest first so the
+// queue is worked through in order
+func (s *ModerationService) GetReports(status, cursor string, limit int) (*models.ReportPage, error) {
+	if status != models.ReportStatusPending && status != models.ReportStatusInReview &&
+		status != models.ReportStatusResolved {
+		return nil, ErrInvalidReportStatus
+	}
+	after, err := models.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultReportPageSize
+	}
+	limit = min(limit, maxReportPageSize)
+
+	// Fetch one extra report to know whether another page follows
+	reports, err := s.ReportRepo.GetReports(status, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.ReportPage{Reports: make([]models.ReportResponse, 0, limit)}
+	if len(reports) > limit {
+		reports = reports[:limit]
+		last := reports[limit-1]
+		page.NextCursor = models.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for i := range reports {
+		page.Reports = append(page.Reports, models.NewReportResponse(&reports[i]))
+	}
+	return page, nil
+}
+
+// ClaimReport assigns a pending report to the moderator so no one else works on it
+func (s *ModerationService) ClaimReport(moderatorID, reportID uuid.UUID) (*models.ReportResponse, error) {
+	claimed, err := s.ReportRepo.ClaimReport(reportID, moderatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.ReportRepo.GetReportByID(reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, ErrReportNotFound
+	}
+	if !claimed {
+		return nil, ErrReportClaimed
+	}
+
+	resp := models.NewReportResponse(report)
+	return &resp, nil
+}
+
+// ResolveReport closes a report the moderator claimed with a decision, which is kept
+// as a permanent record and applied to the reported user's profile
+func (s *ModerationService) ResolveReport(moderatorID, reportID uuid.UUID,
+	req models.ResolveReportRequest) (*models.ModerationDecisionResponse, error) {
+	report, err := s.ReportRepo.GetReportByID(reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, ErrReportNotFound
+	}
+
+	decision := &models.ModerationDecision{
+		ReportID:    report.ID,
+		ModeratorID: moderatorID,
+		ReportedID:  report.ReportedID,
+		Action:      req.Action,
+		Note:        req.Note,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.ReportRepo.ResolveReport(decision); err != nil {
+		return nil, err
+	}
+
+	resp := models.NewModerationDecisionResponse(decision)
+	return &resp, nil
+}
+
+// GetDecisions returns the moderation history of a user, newest first
+func (s *ModerationService) GetDecisions(userID uuid.UUID) ([]models.ModerationDecisionResponse, error) {
+	decisions, err := s.ReportRepo.GetDecisionsForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]models.ModerationDecisionResponse, 0, len(decisions))
+	for i := range decisions {
+		resp = append(resp, models.NewModerationDecisionResponse(&decisions[i]))
+	}
+	return resp, nil
+}
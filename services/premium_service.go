@@ -2,33 +2,55 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 
+	"datingApp/events"
 	"datingApp/models"
 	"datingApp/repositories"
 )
 
+var (
+	ErrPackageNotFound      = errors.New("premium package not found")
+	ErrInvalidPackage       = errors.New("invalid premium package")
+	ErrNothingToRenew       = errors.New("user has no premium subscription to renew")
+	ErrLifetimeSubscription = repositories.ErrLifetimeSubscription
+)
+
 type PremiumServiceInterface interface {
-	RegisterPremium(userID uuid.UUID, packageID uuid.UUID) error
+	RegisterPremium(userID uuid.UUID, packageID uuid.UUID) (*models.UserPremium, error)
+	RenewPremium(userID uuid.UUID) (*models.UserPremium, error)
+	ExpireSubscriptions() (int, error)
+	NotifyExpiringSubscriptions(within time.Duration) (int, error)
 	IsUserPremium(userID uuid.UUID) (bool, error)
 	GetUserPremiumDetails(userID uuid.UUID) (*models.UserPremium, error)
 	GetAllPremiumPackages() ([]models.PremiumPackage, error)
 	GetPremiumPackageByID(packageID uuid.UUID) (*models.PremiumPackage, error)
-	CreatePremiumPackage(name, description string, price decimal.Decimal) error
+	CreatePremiumPackage(name, description string, price decimal.Decimal, durationMonths int,
+		entitlements []models.PackageEntitlement) error
 	UpdatePremiumPackage(pkg *models.PremiumPackage) error
 	DeletePremiumPackage(packageID uuid.UUID) error
+	GetEntitlements(userID uuid.UUID) ([]models.EntitlementResponse, error)
+	GetEntitlement(userID uuid.UUID, feature string) (*models.EntitlementResponse, error)
+	HasEntitlement(userID uuid.UUID, feature string) (bool, error)
 }
 
 type PremiumService struct {
 	premiumRepo repositories.PremiumRepository
+	userRepo    repositories.UserRepository
+	events      events.Publisher
 }
 
-func NewPremiumService(repo repositories.PremiumRepository) *PremiumService {
+func NewPremiumService(repo repositories.PremiumRepository, userRepo repositories.UserRepository,
+	publisher events.Publisher) *PremiumService {
 	return &PremiumService{
 		premiumRepo: repo,
+		userRepo:    userRepo,
+		events:      publisher,
 	}
 }
 
@@ -37,34 +59,94 @@ func (s *PremiumService) GetPremiumPackageByID(packageID uuid.UUID) (*models.Pre
 	return s.premiumRepo.GetPremiumPackageByID(packageID)
 }
 
-// RegisterPremium registers a new premium subscription for a user
-func (s *PremiumService) RegisterPremium(userID uuid.UUID, packageID uuid.UUID) error {
+// RegisterPremium purchases a package for a user. If the user already has a running
+// subscription the new one is stacked and starts when the last one expires.
+func (s *PremiumService) RegisterPremium(userID uuid.UUID, packageID uuid.UUID) (*models.UserPremium, error) {
 	// Validate package exists
 	pkg, err := s.GetPremiumPackageByID(packageID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if pkg == nil {
-		return errors.New("invalid premium package")
-	}
-
-	// Check if user already has an active premium subscription
-	isPremium, err := s.IsUserPremium(userID)
-	if err != nil {
-		return err
-	}
-	if isPremium {
-		return errors.New("user already has an active premium subscription")
+		return nil, ErrPackageNotFound
 	}
 
-	// Create new premium subscription
+	// The repository stacks the purchase after any subscription the user already holds
 	userPremium := &models.UserPremium{
 		UserID:       userID,
 		PackageID:    packageID,
 		PurchaseDate: time.Now(),
+		Status:       models.SubscriptionStatusActive,
+	}
+	if err := s.premiumRepo.RegisterPremium(userPremium, pkg); err != nil {
+		return nil, err
+	}
+	if err := s.syncVerifiedBadge(userID); err != nil {
+		return nil, err
+	}
+	return userPremium, nil
+}
+
+// RenewPremium buys the user's most recent package again, extending their premium period
+func (s *PremiumService) RenewPremium(userID uuid.UUID) (*models.UserPremium, error) {
+	latest, err := s.premiumRepo.GetLatestSubscription(userID)
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil {
+		return nil, ErrNothingToRenew
 	}
 
-	return s.premiumRepo.RegisterPremium(userPremium)
+	return s.RegisterPremium(userID, latest.PackageID)
+}
+
+// ExpireSubscriptions flags subscriptions whose period has ended, revokes perks the
+// affected users no longer hold and returns how many subscriptions were expired
+func (s *PremiumService) ExpireSubscriptions() (int, error) {
+	expired, err := s.premiumRepo.ExpireSubscriptions(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	synced := map[uuid.UUID]bool{}
+	for _, sub := range expired {
+		if synced[sub.UserID] {
+			continue
+		}
+		if err := s.syncVerifiedBadge(sub.UserID); err != nil {
+			return 0, err
+		}
+		synced[sub.UserID] = true
+	}
+	return len(expired), nil
+}
+
+// NotifyExpiringSubscriptions warns the users whose premium ends within the given
+// time, once per subscription, and returns how many were warned
+func (s *PremiumService) NotifyExpiringSubscriptions(within time.Duration) (int, error) {
+	now := time.Now()
+	expiring, err := s.premiumRepo.ClaimExpiringSubscriptions(now, now.Add(within))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, sub := range expiring {
+		publishEvent(s.events, sub.UserID, events.TypeSubscriptionExpiring, models.SubscriptionExpiringResponse{
+			SubscriptionID: sub.ID,
+			PackageID:      sub.PackageID,
+			ExpiresAt:      *sub.ExpiresAt,
+		})
+	}
+	return len(expiring), nil
+}
+
+// syncVerifiedBadge sets the user's verified badge to match their verified badge entitlement
+func (s *PremiumService) syncVerifiedBadge(userID uuid.UUID) error {
+	verified, err := s.HasEntitlement(userID, models.FeatureVerifiedBadge)
+	if err != nil {
+		return err
+	}
+	return s.userRepo.SetVerified(userID, verified)
 }
 
 // IsUserPremium checks if a user has an active premium subscription
@@ -83,15 +165,24 @@ func (s *PremiumService) GetAllPremiumPackages() ([]models.PremiumPackage, error
 }
 
 // CreatePremiumPackage creates a new premium package
-func (s *PremiumService) CreatePremiumPackage(name, description string, price decimal.Decimal) error {
+func (s *PremiumService) CreatePremiumPackage(name, description string, price decimal.Decimal, durationMonths int,
+	entitlements []models.PackageEntitlement) error {
 	if price.IsNegative() {
-		return errors.New("price cannot be negative")
+		return fmt.Errorf("%w: price cannot be negative", ErrInvalidPackage)
+	}
+	if durationMonths < 0 {
+		return fmt.Errorf("%w: duration cannot be negative", ErrInvalidPackage)
+	}
+	if err := validateEntitlements(entitlements); err != nil {
+		return err
 	}
 
 	pk := &models.PremiumPackage{
-		PackageName: name,
-		Description: description,
-		Price:       price,
+		PackageName:    name,
+		Description:    description,
+		Price:          price,
+		DurationMonths: durationMonths,
+		Entitlements:   entitlements,
 	}
 
 	return s.premiumRepo.CreatePremiumPackage(pk)
@@ -100,7 +191,13 @@ func (s *PremiumService) CreatePremiumPackage(name, description string, price de
 // UpdatePremiumPackage updates an existing premium package
 func (s *PremiumService) UpdatePremiumPackage(pkg *models.PremiumPackage) error {
 	if pkg.Price.IsNegative() {
-		return errors.New("price cannot be negative")
+		return fmt.Errorf("%w: price cannot be negative", ErrInvalidPackage)
+	}
+	if pkg.DurationMonths < 0 {
+		return fmt.Errorf("%w: duration cannot be negative", ErrInvalidPackage)
+	}
+	if err := validateEntitlements(pkg.Entitlements); err != nil {
+		return err
 	}
 
 	existing, err := s.GetPremiumPackageByID(pkg.ID)
@@ -108,7 +205,7 @@ func (s *PremiumService) UpdatePremiumPackage(pkg *models.PremiumPackage) error
 		return err
 	}
 	if existing == nil {
-		return errors.New("premium package not found")
+		return ErrPackageNotFound
 	}
 
 	return s.premiumRepo.UpdatePremiumPackage(pkg)
@@ -121,8 +218,81 @@ func (s *PremiumService) DeletePremiumPackage(packageID uuid.UUID) error {
 		return err
 	}
 	if existing == nil {
-		return errors.New("premium package not found")
+		return ErrPackageNotFound
 	}
 
 	return s.premiumRepo.DeletePremiumPackage(packageID)
 }
+
+// GetEntitlements returns the user's effective entitlements across all subscriptions
+// currently covering them. When several packages grant the same feature the most
+// generous limit wins.
+func (s *PremiumService) GetEntitlements(userID uuid.UUID) ([]models.EntitlementResponse, error) {
+	granted, err := s.premiumRepo.GetActiveEntitlements(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byFeature := map[string]*models.EntitlementResponse{}
+	var effective []*models.EntitlementResponse
+	for _, e := range granted {
+		current, ok := byFeature[e.Feature]
+		if !ok {
+			current = &models.EntitlementResponse{Feature: e.Feature, Limit: e.Limit}
+			byFeature[e.Feature] = current
+			effective = append(effective, current)
+			continue
+		}
+		if current.Limit != nil && (e.Limit == nil || *e.Limit > *current.Limit) {
+			current.Limit = e.Limit
+		}
+	}
+
+	resp := make([]models.EntitlementResponse, 0, len(effective))
+	for _, e := range effective {
+		resp = append(resp, *e)
+	}
+	return resp, nil
+}
+
+// GetEntitlement returns the user's effective entitlement for a feature, or nil if they lack it
+func (s *PremiumService) GetEntitlement(userID uuid.UUID, feature string) (*models.EntitlementResponse, error) {
+	entitlements, err := s.GetEntitlements(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entitlements {
+		if entitlements[i].Feature == feature {
+			return &entitlements[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// HasEntitlement reports whether any of the user's active packages grants the feature
+func (s *PremiumService) HasEntitlement(userID uuid.UUID, feature string) (bool, error) {
+	entitlement, err := s.GetEntitlement(userID, feature)
+	if err != nil {
+		return false, err
+	}
+	return entitlement != nil, nil
+}
+
+// validateEntitlements rejects unknown, repeated or non-positive entitlements with
+// ErrInvalidPackage
+func validateEntitlements(entitlements []models.PackageEntitlement) error {
+	seen := map[string]bool{}
+	for _, e := range entitlements {
+		if !slices.Contains(models.Features, e.Feature) {
+			return fmt.Errorf("%w: unknown feature %q", ErrInvalidPackage, e.Feature)
+		}
+		if seen[e.Feature] {
+			return fmt.Errorf("%w: feature %q declared more than once", ErrInvalidPackage, e.Feature)
+		}
+		if e.Limit != nil && *e.Limit < 1 {
+			return fmt.Errorf("%w: limit for feature %q must be positive", ErrInvalidPackage, e.Feature)
+		}
+		seen[e.Feature] = true
+	}
+	return nil
+}
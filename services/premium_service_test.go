@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"datingApp/events"
+	"datingApp/models"
+)
+
+func TestVerifiedBadgeFollowsSubscription(t *testing.T) {
+	tests := []struct {
+		name         string
+		entitlements []models.PackageEntitlement
+		wantVerified bool
+	}{
+		{"package with the badge", []models.PackageEntitlement{{Feature: models.FeatureVerifiedBadge}}, true},
+		{"package without the badge", []models.PackageEntitlement{{Feature: models.FeatureUnlimitedSwipes}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{ID: uuid.New()}
+			users := newFakeUserRepo(user)
+			pkg := &models.PremiumPackage{DurationMonths: 1, Entitlements: tt.entitlements}
+			premium := newFakePremiumRepo(pkg)
+			service := NewPremiumService(premium, users, nil)
+
+			if _, err := service.RegisterPremium(user.ID, pkg.ID); err != nil {
+				t.Fatalf("RegisterPremium: %v", err)
+			}
+			if users.users[user.ID].IsVerified != tt.wantVerified {
+				t.Errorf("verified after purchase = %t, want %t", users.users[user.ID].IsVerified, tt.wantVerified)
+			}
+
+			// Let the subscription run out
+			expired := time.Now().Add(-time.Minute)
+			premium.subscriptions[0].ExpiresAt = &expired
+			count, err := service.ExpireSubscriptions()
+			if err != nil {
+				t.Fatalf("ExpireSubscriptions: %v", err)
+			}
+			if count != 1 {
+				t.Errorf("expired %d subscriptions, want 1", count)
+			}
+			if users.users[user.ID].IsVerified {
+				t.Error("the badge survived the expiry of the subscription")
+			}
+		})
+	}
+}
+
+func TestExpiryKeepsBadgeGrantedByAnotherSubscription(t *testing.T) {
+	user := &models.User{ID: uuid.New()}
+	users := newFakeUserRepo(user)
+	monthly := &models.PremiumPackage{DurationMonths: 1, Entitlements: []models.PackageEntitlement{{Feature: models.FeatureVerifiedBadge}}}
+	premium := newFakePremiumRepo(monthly)
+	service := NewPremiumService(premium, users, nil)
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.RegisterPremium(user.ID, monthly.ID); err != nil {
+			t.Fatalf("RegisterPremium: %v", err)
+		}
+	}
+
+	// The first period ends and the stacked one takes over
+	now := time.Now()
+	premium.subscriptions[0].ExpiresAt = &now
+	premium.subscriptions[1].StartDate = now
+	if _, err := service.ExpireSubscriptions(); err != nil {
+		t.Fatalf("ExpireSubscriptions: %v", err)
+	}
+	if !users.users[user.ID].IsVerified {
+		t.Error("the badge was revoked while the stacked subscription still grants it")
+	}
+}
+
+func TestPurchasesStackAfterTheLastSubscription(t *testing.T) {
+	user := &models.User{ID: uuid.New()}
+	monthly := &models.PremiumPackage{DurationMonths: 1}
+	quarterly := &models.PremiumPackage{DurationMonths: 3}
+	lifetime := &models.PremiumPackage{}
+	premium := newFakePremiumRepo(monthly, quarterly, lifetime)
+	service := NewPremiumService(premium, newFakeUserRepo(user), nil)
+
+	first, err := service.RegisterPremium(user.ID, monthly.ID)
+	if err != nil {
+		t.Fatalf("RegisterPremium: %v", err)
+	}
+	if !first.StartDate.Equal(first.PurchaseDate) {
+		t.Errorf("first subscription starts at %v, want its purchase date %v", first.StartDate, first.PurchaseDate)
+	}
+
+	second, err := service.RegisterPremium(user.ID, quarterly.ID)
+	if err != nil {
+		t.Fatalf("RegisterPremium: %v", err)
+	}
+	if !second.StartDate.Equal(*first.ExpiresAt) {
+		t.Errorf("stacked subscription starts at %v, want %v", second.StartDate, *first.ExpiresAt)
+	}
+	if want := second.StartDate.AddDate(0, 3, 0); !second.ExpiresAt.Equal(want) {
+		t.Errorf("stacked subscription expires at %v, want %v", *second.ExpiresAt, want)
+	}
+
+	// Renewing buys the latest package again after everything already held
+	renewed, err := service.RenewPremium(user.ID)
+	if err != nil {
+		t.Fatalf("RenewPremium: %v", err)
+	}
+	if renewed.PackageID != quarterly.ID {
+		t.Errorf("renewed package %v, want the quarterly one %v", renewed.PackageID, quarterly.ID)
+	}
+	if !renewed.StartDate.Equal(*second.ExpiresAt) {
+		t.Errorf("renewal starts at %v, want %v", renewed.StartDate, *second.ExpiresAt)
+	}
+
+	forever, err := service.RegisterPremium(user.ID, lifetime.ID)
+	if err != nil {
+		t.Fatalf("RegisterPremium lifetime: %v", err)
+	}
+	if forever.ExpiresAt != nil {
+		t.Errorf("lifetime subscription expires at %v", *forever.ExpiresAt)
+	}
+	if _, err := service.RegisterPremium(user.ID, monthly.ID); !errors.Is(err, ErrLifetimeSubscription) {
+		t.Errorf("purchase after a lifetime subscription: err = %v, want %v", err, ErrLifetimeSubscription)
+	}
+}
+
+func TestPurchaseErrors(t *testing.T) {
+	user := &models.User{ID: uuid.New()}
+	service := NewPremiumService(newFakePremiumRepo(), newFakeUserRepo(user), nil)
+
+	if _, err := service.RegisterPremium(user.ID, uuid.New()); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("RegisterPremium unknown package: err = %v, want %v", err, ErrPackageNotFound)
+	}
+	if _, err := service.RenewPremium(user.ID); !errors.Is(err, ErrNothingToRenew) {
+		t.Errorf("RenewPremium without a subscription: err = %v, want %v", err, ErrNothingToRenew)
+	}
+}
+
+func TestExpiredSubscriptionEndsPremium(t *testing.T) {
+	user := &models.User{ID: uuid.New()}
+	monthly := &models.PremiumPackage{DurationMonths: 1}
+	premium := newFakePremiumRepo(monthly)
+	service := NewPremiumService(premium, newFakeUserRepo(user), nil)
+
+	if _, err := service.RegisterPremium(user.ID, monthly.ID); err != nil {
+		t.Fatalf("RegisterPremium: %v", err)
+	}
+	if isPremium, _ := service.IsUserPremium(user.ID); !isPremium {
+		t.Fatal("user is not premium after the purchase")
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	premium.subscriptions[0].ExpiresAt = &expired
+	if count, err := service.ExpireSubscriptions(); err != nil || count != 1 {
+		t.Fatalf("ExpireSubscriptions = %d, %v; want 1, nil", count, err)
+	}
+	if premium.subscriptions[0].Status != models.SubscriptionStatusExpired {
+		t.Errorf("status = %q, want %q", premium.subscriptions[0].Status, models.SubscriptionStatusExpired)
+	}
+	if isPremium, _ := service.IsUserPremium(user.ID); isPremium {
+		t.Error("user is still premium after the subscription expired")
+	}
+	if count, _ := service.ExpireSubscriptions(); count != 0 {
+		t.Errorf("second run expired %d subscriptions, want 0", count)
+	}
+}
+
+func TestExpiryWarningSentOnceAndOnlyWhenPremiumLapses(t *testing.T) {
+	lapsing := &models.User{ID: uuid.New()}
+	renewed := &models.User{ID: uuid.New()}
+	monthly := &models.PremiumPackage{DurationMonths: 1}
+	premium := newFakePremiumRepo(monthly)
+	publisher := &fakePublisher{}
+	service := NewPremiumService(premium, newFakeUserRepo(lapsing, renewed), publisher)
+
+	for _, userID := range []uuid.UUID{lapsing.ID, renewed.ID, renewed.ID} {
+		if _, err := service.RegisterPremium(userID, monthly.ID); err != nil {
+			t.Fatalf("RegisterPremium: %v", err)
+		}
+	}
+	// Bring the first period of both users within the warning window
+	soon := time.Now().Add(time.Hour)
+	premium.subscriptions[0].ExpiresAt = &soon
+	premium.subscriptions[1].ExpiresAt = &soon
+
+	for run := 0; run < 2; run++ {
+		if _, err := service.NotifyExpiringSubscriptions(24 * time.Hour); err != nil {
+			t.Fatalf("NotifyExpiringSubscriptions: %v", err)
+		}
+	}
+	warned := publisher.recipients(events.TypeSubscriptionExpiring)
+	if len(warned) != 1 || warned[0] != lapsing.ID {
+		t.Errorf("warned %v, want only %v", warned, lapsing.ID)
+	}
+}
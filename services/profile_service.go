@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrUsernameTaken   = errors.New("username already in use")
+)
+
+type ProfileService struct {
+	ProfileRepo repositories.ProfileRepository
+	UserRepo    repositories.UserRepository
+	BlockRepo   repositories.BlockRepository
+}
+
+func NewProfileService(profileRepo repositories.ProfileRepository, userRepo repositories.UserRepository,
+	blockRepo repositories.BlockRepository) *ProfileService {
+	return &ProfileService{
+		ProfileRepo: profileRepo,
+		UserRepo:    userRepo,
+		BlockRepo:   blockRepo,
+	}
+}
+
+// GetMyProfile returns the caller's own profile, including private fields
+func (s *ProfileService) GetMyProfile(userID uuid.UUID) (*models.ProfileResponse, error) {
+	profile, err := s.ProfileRepo.GetProfileByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, ErrProfileNotFound
+	}
+	return models.NewProfileResponse(profile), nil
+}
+
+// GetPublicProfile returns the publicly visible part of another user's profile to the
+// viewer. Profiles hidden by moderation or blocked either way are reported as missing.
+func (s *ProfileService) GetPublicProfile(viewerID, userID uuid.UUID) (*models.PublicProfileResponse, error) {
+	profile, err := s.ProfileRepo.GetProfileByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil || profile.User.HiddenAt != nil {
+		return nil, ErrProfileNotFound
+	}
+	blocked, err := s.BlockRepo.IsBlocked(viewerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrProfileNotFound
+	}
+	return models.NewPublicProfileResponse(profile), nil
+}
+
+// UpdateProfile changes only the fields set in the request and returns the updated profile
+func (s *ProfileService) UpdateProfile(userID uuid.UUID, req models.UpdateProfileRequest) (*models.ProfileResponse, error) {
+	userUpdates := map[string]interface{}{}
+	profileUpdates := map[string]interface{}{}
+
+	if req.Username != nil {
+		existing, err := s.UserRepo.GetUserByUsername(*req.Username)
+		if err == nil && existing != nil && existing.ID != userID {
+			return nil, ErrUsernameTaken
+		}
+		userUpdates["username"] = *req.Username
+	}
+	if req.ProfilePicURL != nil {
+		userUpdates["profile_pic_url"] = *req.ProfilePicURL
+	}
+	if req.Bio != nil {
+		profileUpdates["bio"] = *req.Bio
+	}
+	if req.Interests != nil {
+		profileUpdates["interests"] = models.StringList(*req.Interests)
+	}
+	if req.TimeZone != nil {
+		userUpdates["time_zone"] = *req.TimeZone
+	}
+
+	if err := s.ProfileRepo.UpdateProfile(userID, userUpdates, profileUpdates); err != nil {
+		return nil, err
+	}
+	return s.GetMyProfile(userID)
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"datingApp/models"
+)
+
+func TestGetPublicProfileVisibility(t *testing.T) {
+	hiddenAt := time.Now()
+	viewer := &models.User{ID: uuid.New(), Username: "viewer"}
+	visible := &models.User{ID: uuid.New(), Username: "visible"}
+	hidden := &models.User{ID: uuid.New(), Username: "hidden", HiddenAt: &hiddenAt}
+	blocked := &models.User{ID: uuid.New(), Username: "blocked"}
+	blocker := &models.User{ID: uuid.New(), Username: "blocker"}
+	withoutProfile := &models.User{ID: uuid.New(), Username: "without-profile"}
+
+	users := newFakeUserRepo(viewer, visible, hidden, blocked, blocker, withoutProfile)
+	profiles := newFakeProfileRepo(users)
+	for _, user := range []*models.User{viewer, visible, hidden, blocked, blocker} {
+		profiles.profiles[user.ID] = &models.Profile{UserID: user.ID, Bio: "bio of " + user.Username}
+	}
+	blocks := newFakeBlockRepo()
+	blocks.BlockUser(viewer.ID, blocked.ID)
+	blocks.BlockUser(blocker.ID, viewer.ID)
+	service := NewProfileService(profiles, users, blocks)
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		wantErr error
+	}{
+		{"visible user", visible.ID, nil},
+		{"own profile", viewer.ID, nil},
+		{"hidden by moderation", hidden.ID, ErrProfileNotFound},
+		{"blocked by the viewer", blocked.ID, ErrProfileNotFound},
+		{"blocked the viewer", blocker.ID, ErrProfileNotFound},
+		{"user without a profile", withoutProfile.ID, ErrProfileNotFound},
+		{"unknown user", uuid.New(), ErrProfileNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := service.GetPublicProfile(viewer.ID, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetPublicProfile error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && profile.UserID != tt.userID {
+				t.Errorf("got the profile of %s, want %s", profile.UserID, tt.userID)
+			}
+		})
+	}
+}
+
+func TestUpdateProfileChangesOnlyTheGivenFields(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Username: "before", ProfilePicURL: "https://example.com/a.png", TimeZone: "UTC"}
+	taken := &models.User{ID: uuid.New(), Username: "taken"}
+	users := newFakeUserRepo(user, taken)
+	profiles := newFakeProfileRepo(users, &models.Profile{UserID: user.ID, Bio: "old bio", Interests: models.StringList{"chess"}})
+	service := NewProfileService(profiles, users, newFakeBlockRepo())
+
+	bio := "new bio"
+	updated, err := service.UpdateProfile(user.ID, models.UpdateProfileRequest{Bio: &bio})
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if updated.Bio != bio || updated.Username != "before" || updated.ProfilePicURL != user.ProfilePicURL ||
+		updated.TimeZone != "UTC" || len(updated.Interests) != 1 {
+		t.Errorf("updating the bio changed other fields: %+v", updated)
+	}
+
+	username := "taken"
+	if _, err := service.UpdateProfile(user.ID, models.UpdateProfileRequest{Username: &username}); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("taking another user's username: err = %v, want %v", err, ErrUsernameTaken)
+	}
+	if users.users[user.ID].Username != "before" {
+		t.Errorf("username changed to %q despite the conflict", users.users[user.ID].Username)
+	}
+
+	if _, err := service.GetMyProfile(taken.ID); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("GetMyProfile without a profile: err = %v, want %v", err, ErrProfileNotFound)
+	}
+}
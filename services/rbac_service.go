@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"datingApp/events"
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+var (
+	ErrRoleNotFound         = errors.New("role not found")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrLastSuperAdmin       = errors.New("cannot remove the last super admin")
+	ErrSuperAdminExists     = errors.New("a super admin already exists")
+	ErrBootstrapUserInvalid = errors.New("email, username and password are required")
+)
+
+type RBACService struct {
+	RoleRepo    repositories.RoleRepository
+	UserRepo    repositories.UserRepository
+	SessionRepo repositories.SessionRepository
+	Events      events.Publisher
+}
+
+func NewRBACService(roleRepo repositories.RoleRepository, userRepo repositories.UserRepository,
+	sessionRepo repositories.SessionRepository, publisher events.Publisher) *RBACService {
+	return &RBACService{
+		RoleRepo:    roleRepo,
+		UserRepo:    userRepo,
+		SessionRepo: sessionRepo,
+		Events:      publisher,
+	}
+}
+
+// HasPermission reports whether any of the user's roles grants the permission
+func (s *RBACService) HasPermission(userID uuid.UUID, permission string) (bool, error) {
+	return s.RoleRepo.UserHasPermission(userID, permission)
+}
+
+// GetRoles lists every role together with the permissions it grants
+func (s *RBACService) GetRoles() ([]models.RoleResponse, error) {
+	roles, err := s.RoleRepo.GetRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]models.RoleResponse, 0, len(roles))
+	for i := range roles {
+		resp = append(resp, models.NewRoleResponse(&roles[i]))
+	}
+	return resp, nil
+}
+
+// GetUserRoles returns the names of the roles granted to the user
+func (s *RBACService) GetUserRoles(userID uuid.UUID) ([]string, error) {
+	if _, err := s.UserRepo.GetUserByID(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.RoleRepo.GetUserRoleNames(userID)
+}
+
+// AssignRole grants the named role to a user on behalf of actorID
+func (s *RBACService) AssignRole(actorID, userID uuid.UUID, roleName string) error {
+	if _, err := s.UserRepo.GetUserByID(userID); err != nil {
+		return ErrUserNotFound
+	}
+	role, err := s.RoleRepo.GetRoleByName(roleName)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return ErrRoleNotFound
+	}
+
+	return s.RoleRepo.AssignRole(&models.UserRole{
+		UserID:      userID,
+		RoleID:      role.ID,
+		GrantedByID: &actorID,
+	})
+}
+
+// RemoveRole revokes the named role from a user, always keeping at least one super admin
+func (s *RBACService) RemoveRole(userID uuid.UUID, roleName string) error {
+	role, err := s.RoleRepo.GetRoleByName(roleName)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return ErrRoleNotFound
+	}
+
+	if role.Name != models.RoleSuperAdmin {
+		return s.RoleRepo.RemoveRole(userID, role.ID)
+	}
+	err = s.RoleRepo.RemoveRoleKeepingLast(userID, role.ID)
+	if errors.Is(err, repositories.ErrLastRoleHolder) {
+		return ErrLastSuperAdmin
+	}
+	return err
+}
+
+// RevokeUserSessions logs the user out everywhere and closes their open streams
+func (s *RBACService) RevokeUserSessions(userID uuid.UUID) error {
+	if _, err := s.UserRepo.GetUserByID(userID); err != nil {
+		return ErrUserNotFound
+	}
+	if err := s.SessionRepo.RevokeUserSessions(userID); err != nil {
+		return err
+	}
+	publishSessionRevoked(s.Events, userID, nil)
+	return nil
+}
+
+// BootstrapSuperAdmin creates the first super admin. The account is created if the
+// email is not registered yet; an existing account is promoted and keeps its password.
+// It refuses to run once any super admin exists.
+func (s *RBACService) BootstrapSuperAdmin(email, username, password string) (*models.User, error) {
+	if email == "" || username == "" || password == "" {
+		return nil, ErrBootstrapUserInvalid
+	}
+
+	role, err := s.RoleRepo.GetRoleByName(models.RoleSuperAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+	count, err := s.RoleRepo.CountUsersWithRole(role.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrSuperAdminExists
+	}
+
+	user, err := s.UserRepo.GetUserByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		hashedPassword, err := hashPassword(password)
+		if err != nil {
+			return nil, errors.New("failed to hash password")
+		}
+		user = &models.User{
+			Email:        email,
+			PasswordHash: hashedPassword,
+			Username:     username,
+		}
+		if err := s.UserRepo.CreateUserWithProfile(user, &models.Profile{}); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else {
+		log.Printf("Promoting existing account %s to super admin; the supplied password was ignored", user.Email)
+	}
+
+	err = s.RoleRepo.AssignRole(&models.UserRole{UserID: user.ID, RoleID: role.ID})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
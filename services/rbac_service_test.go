@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"datingApp/models"
+)
+
+// brokenEmailLookup fails every email lookup as an unreachable database would
+type brokenEmailLookup struct {
+	*fakeUserRepo
+}
+
+var errDatabaseDown = errors.New("database is down")
+
+func (r brokenEmailLookup) GetUserByEmail(string) (*models.User, error) {
+	return nil, errDatabaseDown
+}
+
+func TestBootstrapSuperAdmin(t *testing.T) {
+	existing := &models.User{ID: uuid.New(), Email: "admin@example.com", Username: "admin", PasswordHash: "original-hash"}
+
+	t.Run("creates a missing account", func(t *testing.T) {
+		users, roles := newFakeUserRepo(), newFakeRoleRepo(models.RoleSuperAdmin)
+		service := NewRBACService(roles, users, nil, nil)
+
+		user, err := service.BootstrapSuperAdmin("new@example.com", "new", "secret-password")
+		if err != nil {
+			t.Fatalf("BootstrapSuperAdmin: %v", err)
+		}
+		if _, err := users.GetUserByEmail("new@example.com"); err != nil {
+			t.Errorf("the account was not created: %v", err)
+		}
+		assertRoles(t, roles, user.ID, models.RoleSuperAdmin)
+	})
+
+	t.Run("promotes an existing account and keeps its password", func(t *testing.T) {
+		users, roles := newFakeUserRepo(existing), newFakeRoleRepo(models.RoleSuperAdmin)
+		service := NewRBACService(roles, users, nil, nil)
+
+		user, err := service.BootstrapSuperAdmin(existing.Email, "other", "ignored-password")
+		if err != nil {
+			t.Fatalf("BootstrapSuperAdmin: %v", err)
+		}
+		if user.ID != existing.ID {
+			t.Errorf("promoted %s, want the existing account %s", user.ID, existing.ID)
+		}
+		stored, _ := users.GetUserByID(existing.ID)
+		if stored.PasswordHash != "original-hash" || stored.Username != "admin" {
+			t.Errorf("the existing account changed: %+v", stored)
+		}
+		if len(users.users) != 1 {
+			t.Errorf("%d accounts exist, want 1", len(users.users))
+		}
+		assertRoles(t, roles, existing.ID, models.RoleSuperAdmin)
+	})
+
+	t.Run("surfaces lookup errors without creating an account", func(t *testing.T) {
+		users, roles := newFakeUserRepo(), newFakeRoleRepo(models.RoleSuperAdmin)
+		service := NewRBACService(roles, brokenEmailLookup{users}, nil, nil)
+
+		if _, err := service.BootstrapSuperAdmin(existing.Email, "admin", "secret-password"); !errors.Is(err, errDatabaseDown) {
+			t.Errorf("BootstrapSuperAdmin error = %v, want %v", err, errDatabaseDown)
+		}
+		if len(users.users) != 0 {
+			t.Errorf("%d accounts were created", len(users.users))
+		}
+	})
+
+	t.Run("refuses once a super admin exists", func(t *testing.T) {
+		users, roles := newFakeUserRepo(existing), newFakeRoleRepo(models.RoleSuperAdmin)
+		service := NewRBACService(roles, users, nil, nil)
+		if _, err := service.BootstrapSuperAdmin(existing.Email, "admin", "secret-password"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := service.BootstrapSuperAdmin("new@example.com", "new", "secret-password"); !errors.Is(err, ErrSuperAdminExists) {
+			t.Errorf("BootstrapSuperAdmin error = %v, want %v", err, ErrSuperAdminExists)
+		}
+	})
+}
+
+func TestRemoveRoleKeepsLastSuperAdmin(t *testing.T) {
+	alice, bob := &models.User{ID: uuid.New()}, &models.User{ID: uuid.New()}
+	roles := newFakeRoleRepo(models.RoleSuperAdmin, models.RoleModerator)
+	service := NewRBACService(roles, newFakeUserRepo(alice, bob), nil, nil)
+	for _, user := range []*models.User{alice, bob} {
+		if err := service.AssignRole(alice.ID, user.ID, models.RoleSuperAdmin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := service.AssignRole(alice.ID, alice.ID, models.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.RemoveRole(bob.ID, models.RoleSuperAdmin); err != nil {
+		t.Fatalf("removing one of two super admins: %v", err)
+	}
+	if err := service.RemoveRole(alice.ID, models.RoleSuperAdmin); !errors.Is(err, ErrLastSuperAdmin) {
+		t.Errorf("removing the last super admin: error = %v, want %v", err, ErrLastSuperAdmin)
+	}
+	if err := service.RemoveRole(alice.ID, models.RoleModerator); err != nil {
+		t.Errorf("removing the last moderator: %v", err)
+	}
+	if err := service.RemoveRole(alice.ID, "no_such_role"); !errors.Is(err, ErrRoleNotFound) {
+		t.Errorf("removing an unknown role: error = %v, want %v", err, ErrRoleNotFound)
+	}
+	assertRoles(t, roles, alice.ID, models.RoleSuperAdmin)
+}
+
+func assertRoles(t *testing.T, roles *fakeRoleRepo, userID uuid.UUID, want ...string) {
+	t.Helper()
+	got, _ := roles.GetUserRoleNames(userID)
+	if len(got) != len(want) {
+		t.Fatalf("user holds %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("user holds %v, want %v", got, want)
+		}
+	}
+}
@@ -5,67 +5,413 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
+	"datingApp/events"
 	"datingApp/models"
 	"datingApp/repositories"
 )
 
+const (
+	defaultCandidatePageSize = 20
+	maxCandidatePageSize     = 50
+)
+
+var (
+	ErrSwipeExists      = repositories.ErrSwipeExists
+	ErrSwipeMatched     = repositories.ErrSwipeMatched
+	ErrQuotaExceeded    = repositories.ErrQuotaExceeded
+	ErrNoSuperLikesLeft = repositories.ErrSuperLikesExhausted
+	ErrNothingToUndo    = repositories.ErrNothingToUndo
+	ErrUndoLimitReached = repositories.ErrUndoLimitReached
+	ErrRewindNotAllowed = errors.New("undoing swipes requires a premium package with rewind")
+	ErrSwipeNotFound    = errors.New("you have not swiped on this user yet")
+	ErrCannotSwipeSelf  = errors.New("cannot swipe on your own profile")
+	ErrTargetNotFound   = errors.New("target user not found")
+)
+
+// SwipeConfig holds the tunable rules of the swipe subsystem
+type SwipeConfig struct {
+	// FreeDailyQuota is the number of daily swipes for users without premium
+	FreeDailyQuota int
+	// FreeDailySuperLikes is the number of daily super likes for users without premium
+	FreeDailySuperLikes int
+	// PassRecycleAfter is how long a pass hides a profile from discovery; 0 hides it forever
+	PassRecycleAfter time.Duration
+	// UndoWindow is how long after a swipe it can still be undone
+	UndoWindow time.Duration
+	// RewindDailyLimit caps daily undos for rewind entitlements that set no limit
+	RewindDailyLimit int
+}
+
 type SwipeService struct {
 	UserRepo  repositories.UserRepository
 	SwipeRepo repositories.SwipeRepository
+	BlockRepo repositories.BlockRepository
+	Premium   PremiumServiceInterface
+	Config    SwipeConfig
+	Events    events.Publisher
+	// now tells the time quota days and the undo and recycle windows are counted from
+	now func() time.Time
 }
 
-func NewSwipeService(userRepo repositories.UserRepository, swipeRepo repositories.SwipeRepository) *SwipeService {
+func NewSwipeService(userRepo repositories.UserRepository, swipeRepo repositories.SwipeRepository,
+	blockRepo repositories.BlockRepository, premium PremiumServiceInterface, config SwipeConfig,
+	publisher events.Publisher) *SwipeService {
 	return &SwipeService{
 		UserRepo:  userRepo,
 		SwipeRepo: swipeRepo,
+		BlockRepo: blockRepo,
+		Premium:   premium,
+		Config:    config,
+		Events:    publisher,
+		now:       time.Now,
 	}
 }
 
-// SwipeRight handles a "like" action
-func (s *SwipeService) SwipeRight(userID, profileID uuid.UUID) error {
-	if userID == profileID {
-		return errors.New("cannot swipe on your own profile")
+// SwipeRight handles a "like" action and returns the match when the like is mutual
+func (s *SwipeService) SwipeRight(userID, targetUserID uuid.UUID) (*models.Match, error) {
+	if err := s.validateTarget(userID, targetUserID); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNotSwiped(userID, targetUserID); err != nil {
+		return nil, err
 	}
 
-	// Check daily swipe quota
-	count, err := s.SwipeRepo.GetDailySwipeCount(userID)
+	charge, err := s.quotaCharge(userID, false)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Record swipe, consume the daily quota and detect a reciprocal like
+	match, err := s.SwipeRepo.RecordSwipe(userID, targetUserID, models.SwipeTypeLike, s.passRecycleBefore(), charge)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyLike(targetUserID, models.SwipeTypeLike, match)
+	return match, nil
+}
+
+// SwipeSuper handles a "super like" action, a like that is shown prominently to the
+// target. It uses the separate daily super like allowance instead of the swipe quota
+// and returns the match when the like is mutual.
+func (s *SwipeService) SwipeSuper(userID, targetUserID uuid.UUID) (*models.Match, error) {
+	if err := s.validateTarget(userID, targetUserID); err != nil {
+		return nil, err
 	}
-	if count >= 10 {
-		return errors.New("daily swipe quota exceeded")
+
+	if err := s.checkNotSwiped(userID, targetUserID); err != nil {
+		return nil, err
 	}
 
-	// Record swipe
-	return s.SwipeRepo.RecordSwipe(userID, profileID, true)
+	charge, err := s.quotaCharge(userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record swipe, consume a super like and detect a reciprocal like
+	match, err := s.SwipeRepo.RecordSwipe(userID, targetUserID, models.SwipeTypeSuperLike, s.passRecycleBefore(), charge)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyLike(targetUserID, models.SwipeTypeSuperLike, match)
+	return match, nil
 }
 
 // SwipeLeft handles a "pass" action
-func (s *SwipeService) SwipeLeft(userID, profileID uuid.UUID) error {
-	if userID == profileID {
-		return errors.New("cannot swipe on your own profile")
+func (s *SwipeService) SwipeLeft(userID, targetUserID uuid.UUID) error {
+	if err := s.validateTarget(userID, targetUserID); err != nil {
+		return err
 	}
 
-	// Check daily swipe quota
-	count, err := s.SwipeRepo.GetDailySwipeCount(userID)
+	if err := s.checkNotSwiped(userID, targetUserID); err != nil {
+		return err
+	}
+
+	charge, err := s.quotaCharge(userID, false)
 	if err != nil {
 		return err
 	}
-	if count >= 10 {
-		return errors.New("daily swipe quota exceeded")
+
+	// Record swipe and consume the daily quota
+	_, err = s.SwipeRepo.RecordSwipe(userID, targetUserID, models.SwipeTypePass, s.passRecycleBefore(), charge)
+	return err
+}
+
+// ChangeSwipe turns an existing swipe into a like or a pass and returns the match
+// when a new like turns out to be mutual. Changing to the current decision is a
+// no-op, and a like cannot be withdrawn while the two users are matched. The action
+// is models.SwipeTypeLike or models.SwipeTypePass.
+func (s *SwipeService) ChangeSwipe(userID, targetUserID uuid.UUID, action string) (*models.Match, error) {
+	if err := s.validateTarget(userID, targetUserID); err != nil {
+		return nil, err
+	}
+
+	swipe, err := s.SwipeRepo.GetSwipe(userID, targetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if swipe == nil {
+		return nil, ErrSwipeNotFound
+	}
+
+	match, err := s.SwipeRepo.ChangeSwipe(userID, targetUserID, action)
+	if err != nil {
+		return nil, err
+	}
+	// Only a pass turned into a like is news to the target
+	if action == models.SwipeTypeLike && !swipe.IsLike() {
+		s.notifyLike(targetUserID, models.SwipeTypeLike, match)
+	}
+	return match, nil
+}
+
+// UndoLastSwipe takes back the user's most recent swipe if it is still within the
+// undo window. The swipe's quota is refunded and a match it created ends as unmatched
+// by the user, which both users are told about.
+// Undoing requires the rewind entitlement, whose limit caps the undos per day.
+func (s *SwipeService) UndoLastSwipe(userID uuid.UUID) (*models.Swipe, error) {
+	entitlement, err := s.Premium.GetEntitlement(userID, models.FeatureRewind)
+	if err != nil {
+		return nil, err
+	}
+	if entitlement == nil {
+		return nil, ErrRewindNotAllowed
+	}
+	dailyLimit := s.Config.RewindDailyLimit
+	if entitlement.Limit != nil {
+		dailyLimit = *entitlement.Limit
+	}
+
+	user, err := s.UserRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().In(user.Location())
+	swipe, ended, err := s.SwipeRepo.UndoLastSwipe(userID, now.Add(-s.Config.UndoWindow), now, dailyLimit)
+	if err != nil {
+		return nil, err
+	}
+	if ended != nil {
+		publishMatchEnded(s.Events, ended)
+	}
+	return swipe, nil
+}
+
+// GetQuota reports how many swipes and super likes the user has used today and when
+// the quota resets. The day is counted in the user's own time zone.
+func (s *SwipeService) GetQuota(userID uuid.UUID) (*models.SwipeQuotaResponse, error) {
+	limit, unlimited, err := s.dailyLimit(userID)
+	if err != nil {
+		return nil, err
+	}
+	superLimit, superUnlimited, err := s.dailySuperLikes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.UserRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
 	}
+	loc := user.Location()
 
-	// Record swipe
-	return s.SwipeRepo.RecordSwipe(userID, profileID, false)
+	now := s.now().In(loc)
+	used, err := s.SwipeRepo.GetQuota(userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := &models.SwipeQuotaResponse{
+		Used:      used.Used,
+		Unlimited: unlimited,
+		SuperLikes: models.SuperLikeQuotaResponse{
+			Used:      used.SuperUsed,
+			Unlimited: superUnlimited,
+		},
+		TimeZone: loc.String(),
+		ResetsAt: nextReset(now),
+	}
+	if !unlimited {
+		quota.Limit = limit
+		quota.Remaining = max(limit-used.Used, 0)
+	}
+	if !superUnlimited {
+		quota.SuperLikes.Limit = superLimit
+		quota.SuperLikes.Remaining = max(superLimit-used.SuperUsed, 0)
+	}
+	return quota, nil
 }
 
-// GetPotentialMatches returns profiles that the user has not swiped on yet today
-func (s *SwipeService) GetPotentialMatches(userID uuid.UUID) ([]models.User, error) {
-	swipedIDs, err := s.SwipeRepo.GetSwipedUserIDs(userID, time.Now())
+// GetPotentialMatches returns a page of profiles the user has never swiped on, with
+// users who super liked them first. Passed profiles come back once the pass recycle
+// window has elapsed.
+func (s *SwipeService) GetPotentialMatches(userID uuid.UUID, cursor string, limit int) (*models.CandidatePage, error) {
+	after, err := models.DecodeCursor(cursor)
 	if err != nil {
 		return nil, err
 	}
+	if limit <= 0 {
+		limit = defaultCandidatePageSize
+	}
+	limit = min(limit, maxCandidatePageSize)
+
+	// Fetch one extra user to know whether another page follows
+	candidates, err := s.UserRepo.GetCandidates(userID, s.passRecycleBefore(), after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.CandidatePage{Candidates: make([]models.CandidateResponse, 0, limit)}
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+		last := candidates[limit-1]
+		page.NextCursor = models.Cursor{CreatedAt: last.CreatedAt, ID: last.ID, SuperLiked: last.SuperLikedYou}.Encode()
+	}
+	for i := range candidates {
+		page.Candidates = append(page.Candidates, models.NewCandidateResponse(&candidates[i]))
+	}
+	return page, nil
+}
+
+// notifyLike tells the target about a new like, or both users about the match when
+// the like was mutual
+func (s *SwipeService) notifyLike(targetUserID uuid.UUID, swipeType string, match *models.Match) {
+	if match == nil {
+		publishEvent(s.Events, targetUserID, events.TypeLikeReceived, models.LikeReceivedResponse{
+			SuperLike: swipeType == models.SwipeTypeSuperLike,
+			LikedAt:   time.Now(),
+		})
+		return
+	}
+
+	// Each user sees the match from their own point of view
+	for _, userID := range []uuid.UUID{match.UserAID, match.UserBID} {
+		publishEvent(s.Events, userID, events.TypeMatchCreated, toMatchResponse(match, userID))
+	}
+}
+
+// validateTarget checks that the swipe targets another, existing user. A user hidden by
+// moderation or blocked either way is reported as missing so neither is revealed.
+func (s *SwipeService) validateTarget(userID, targetUserID uuid.UUID) error {
+	if userID == targetUserID {
+		return ErrCannotSwipeSelf
+	}
+	target, err := s.UserRepo.GetUserByID(targetUserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTargetNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if target.HiddenAt != nil {
+		return ErrTargetNotFound
+	}
+	blocked, err := s.BlockRepo.IsBlocked(userID, targetUserID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrTargetNotFound
+	}
+	return nil
+}
+
+// checkNotSwiped returns ErrSwipeExists when the user already swiped on the target.
+// A recycled pass does not count, as discovery shows the target again.
+func (s *SwipeService) checkNotSwiped(userID, targetUserID uuid.UUID) error {
+	swipe, err := s.SwipeRepo.GetSwipe(userID, targetUserID)
+	if err != nil {
+		return err
+	}
+	if swipe == nil {
+		return nil
+	}
+	if recycleBefore := s.passRecycleBefore(); recycleBefore != nil &&
+		swipe.SwipeType == models.SwipeTypePass && swipe.SwipeDate.Before(*recycleBefore) {
+		return nil
+	}
+	return ErrSwipeExists
+}
+
+// passRecycleBefore returns the time passes must be older than to be recycled, or nil
+// when passes never expire
+func (s *SwipeService) passRecycleBefore() *time.Time {
+	if s.Config.PassRecycleAfter <= 0 {
+		return nil
+	}
+	t := s.now().Add(-s.Config.PassRecycleAfter)
+	return &t
+}
+
+// quotaCharge returns the quota slot a swipe made now consumes, on the current day
+// in the user's time zone. Super likes are charged to their own allowance. The limit
+// is only enforced when the swipe is recorded, atomically with it.
+func (s *SwipeService) quotaCharge(userID uuid.UUID, super bool) (repositories.QuotaCharge, error) {
+	dailyLimit := s.dailyLimit
+	if super {
+		dailyLimit = s.dailySuperLikes
+	}
+	limit, unlimited, err := dailyLimit(userID)
+	if err != nil {
+		return repositories.QuotaCharge{}, err
+	}
+
+	user, err := s.UserRepo.GetUserByID(userID)
+	if err != nil {
+		return repositories.QuotaCharge{}, err
+	}
+
+	charge := repositories.QuotaCharge{Day: s.now().In(user.Location()), Super: super}
+	if !unlimited {
+		charge.Limit = &limit
+	}
+	return charge, nil
+}
+
+// dailyLimit returns the user's daily swipe allowance. The unlimited swipes entitlement
+// lifts the quota, or raises it to the package-defined limit when one is set.
+func (s *SwipeService) dailyLimit(userID uuid.UUID) (int, bool, error) {
+	entitlement, err := s.Premium.GetEntitlement(userID, models.FeatureUnlimitedSwipes)
+	if err != nil {
+		return 0, false, err
+	}
+	if entitlement == nil {
+		return s.Config.FreeDailyQuota, false, nil
+	}
+	if entitlement.Limit == nil {
+		return 0, true, nil
+	}
+	return max(*entitlement.Limit, s.Config.FreeDailyQuota), false, nil
+}
+
+// dailySuperLikes returns the user's daily super like allowance. The super likes
+// entitlement raises it to the package-defined limit, or lifts it without one.
+func (s *SwipeService) dailySuperLikes(userID uuid.UUID) (int, bool, error) {
+	entitlement, err := s.Premium.GetEntitlement(userID, models.FeatureSuperLikes)
+	if err != nil {
+		return 0, false, err
+	}
+	if entitlement == nil {
+		return s.Config.FreeDailySuperLikes, false, nil
+	}
+	if entitlement.Limit == nil {
+		return 0, true, nil
+	}
+	return max(*entitlement.Limit, s.Config.FreeDailySuperLikes), false, nil
+}
+
+// startOfDay returns midnight at the beginning of t's day in t's location, when the
+// daily quota window opens
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
 
-	return s.UserRepo.GetUnswipedUsers(userID, swipedIDs)
+// nextReset returns the start of the day following t in t's location, when the daily
+// quota resets
+func nextReset(t time.Time) time.Time {
+	return startOfDay(t).AddDate(0, 0, 1)
 }
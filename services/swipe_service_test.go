@@ -0,0 +1,371 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+	// Keep the test independent of the host's zoneinfo database
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+
+	"datingApp/events"
+	"datingApp/models"
+	"datingApp/repositories"
+)
+
+// newTestSwipeService wires a swipe service to in-memory repositories; nobody holds
+// premium, so the free allowances apply
+func newTestSwipeService(users repositories.UserRepository, swipes *fakeSwipeRepo, blocks *fakeBlockRepo) *SwipeService {
+	premium := NewPremiumService(newFakePremiumRepo(), users, nil)
+	config := SwipeConfig{FreeDailyQuota: 10, FreeDailySuperLikes: 1}
+	return NewSwipeService(users, swipes, blocks, premium, config, nil)
+}
+
+// failingUserLookup fails lookups of one user as an unreachable database would
+type failingUserLookup struct {
+	*fakeUserRepo
+	failID uuid.UUID
+}
+
+func (r failingUserLookup) GetUserByID(userID uuid.UUID) (*models.User, error) {
+	if userID == r.failID {
+		return nil, errDatabaseDown
+	}
+	return r.fakeUserRepo.GetUserByID(userID)
+}
+
+func TestSwipeRejectsUnavailableTargets(t *testing.T) {
+	hiddenAt := time.Now()
+	user := &models.User{ID: uuid.New()}
+	visible := &models.User{ID: uuid.New()}
+	hidden := &models.User{ID: uuid.New(), HiddenAt: &hiddenAt}
+	blocked := &models.User{ID: uuid.New()}
+	blocker := &models.User{ID: uuid.New()}
+	unreachable := &models.User{ID: uuid.New()}
+
+	tests := []struct {
+		name    string
+		target  uuid.UUID
+		wantErr error
+	}{
+		{"visible user", visible.ID, nil},
+		{"self", user.ID, ErrCannotSwipeSelf},
+		{"missing user", uuid.New(), ErrTargetNotFound},
+		{"hidden user", hidden.ID, ErrTargetNotFound},
+		{"user blocked by the swiper", blocked.ID, ErrTargetNotFound},
+		{"user who blocked the swiper", blocker.ID, ErrTargetNotFound},
+		{"failed lookup", unreachable.ID, errDatabaseDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := failingUserLookup{newFakeUserRepo(user, visible, hidden, blocked, blocker, unreachable), unreachable.ID}
+			swipes, blocks := newFakeSwipeRepo(), newFakeBlockRepo()
+			blocks.BlockUser(user.ID, blocked.ID)
+			blocks.BlockUser(blocker.ID, user.ID)
+			service := newTestSwipeService(users, swipes, blocks)
+
+			actions := map[string]func() error{
+				"like": func() error {
+					_, err := service.SwipeRight(user.ID, tt.target)
+					return err
+				},
+				"super like": func() error {
+					_, err := service.SwipeSuper(user.ID, tt.target)
+					return err
+				},
+				"pass": func() error {
+					return service.SwipeLeft(user.ID, tt.target)
+				},
+			}
+			for action, swipe := range actions {
+				delete(swipes.swipes, [2]uuid.UUID{user.ID, tt.target})
+				if err := swipe(); !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s error = %v, want %v", action, err, tt.wantErr)
+				}
+			}
+			if tt.wantErr != nil && len(swipes.charges) != 0 {
+				t.Errorf("a rejected swipe charged the quota %d times", len(swipes.charges))
+			}
+		})
+	}
+}
+
+func TestQuotaDayAcrossTheDateLine(t *testing.T) {
+	// Noon UTC is already the next day in Kiritimati (UTC+14) and still the same day
+	// in Pago Pago (UTC-11), so the two users are on different quota days
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		timeZone     string
+		now          time.Time
+		wantDay      string
+		wantResetsAt time.Time
+	}{
+		{"Pacific/Kiritimati", now, "2026-03-11", time.Date(2026, time.March, 11, 10, 0, 0, 0, time.UTC)},
+		{"Pacific/Pago_Pago", now, "2026-03-10", time.Date(2026, time.March, 11, 11, 0, 0, 0, time.UTC)},
+		{"UTC", now, "2026-03-10", time.Date(2026, time.March, 11, 0, 0, 0, 0, time.UTC)},
+		// The day DST starts is only 23 hours long
+		{"America/New_York", time.Date(2026, time.March, 8, 12, 0, 0, 0, time.UTC), "2026-03-08", time.Date(2026, time.March, 9, 4, 0, 0, 0, time.UTC)},
+		{"Not/A_Zone", now, "2026-03-10", time.Date(2026, time.March, 11, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timeZone, func(t *testing.T) {
+			user := &models.User{TimeZone: tt.timeZone}
+			local := tt.now.In(user.Location())
+
+			if day := startOfDay(local).Format(time.DateOnly); day != tt.wantDay {
+				t.Errorf("quota day = %s, want %s", day, tt.wantDay)
+			}
+			if resetsAt := nextReset(local); !resetsAt.Equal(tt.wantResetsAt) {
+				t.Errorf("ResetsAt = %v, want %v", resetsAt.UTC(), tt.wantResetsAt)
+			}
+		})
+	}
+}
+
+func TestSwipeOnRecycledPass(t *testing.T) {
+	tests := []struct {
+		name      string
+		swipeType string
+		age       time.Duration
+		wantErr   error
+	}{
+		{"recycled pass", models.SwipeTypePass, 48 * time.Hour, nil},
+		{"recent pass", models.SwipeTypePass, time.Hour, ErrSwipeExists},
+		{"old like", models.SwipeTypeLike, 48 * time.Hour, ErrSwipeExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, target := &models.User{ID: uuid.New()}, &models.User{ID: uuid.New()}
+			swipes := newFakeSwipeRepo()
+			swipedAt := time.Now().Add(-tt.age)
+			swipes.swipes[[2]uuid.UUID{user.ID, target.ID}] = &models.Swipe{
+				UserID: user.ID, TargetUserID: target.ID, SwipeType: tt.swipeType, SwipeDate: swipedAt, CreatedAt: swipedAt,
+			}
+			service := newTestSwipeService(newFakeUserRepo(user, target), swipes, newFakeBlockRepo())
+			service.Config.PassRecycleAfter = 24 * time.Hour
+
+			if _, err := service.SwipeRight(user.ID, target.ID); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SwipeRight error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && len(swipes.charges) != 1 {
+				t.Errorf("the swipe was charged %d times, want once", len(swipes.charges))
+			}
+		})
+	}
+}
+
+func TestSwipeQuotaFollowsUserTimeZone(t *testing.T) {
+	// 09:30 UTC is 23:30 in Kiritimati (UTC+14) and 22:30 the day before in Pago Pago
+	// (UTC-11). An hour later Kiritimati has started a new quota day, Pago Pago has not.
+	swipedAt := time.Date(2026, time.March, 10, 9, 30, 0, 0, time.UTC)
+	checkedAt := swipedAt.Add(time.Hour)
+
+	tests := []struct {
+		timeZone          string
+		wantDay           string
+		wantResetsAt      time.Time
+		wantUsedLater     int
+		wantResetsAtLater time.Time
+	}{
+		{
+			"Pacific/Kiritimati", "2026-03-10", time.Date(2026, time.March, 10, 10, 0, 0, 0, time.UTC),
+			0, time.Date(2026, time.March, 11, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			"Pacific/Pago_Pago", "2026-03-09", time.Date(2026, time.March, 10, 11, 0, 0, 0, time.UTC),
+			1, time.Date(2026, time.March, 10, 11, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timeZone, func(t *testing.T) {
+			user := &models.User{ID: uuid.New(), TimeZone: tt.timeZone}
+			target := &models.User{ID: uuid.New()}
+			swipes := newFakeSwipeRepo()
+			service := newTestSwipeService(newFakeUserRepo(user, target), swipes, newFakeBlockRepo())
+			service.now = func() time.Time { return swipedAt }
+
+			if _, err := service.SwipeRight(user.ID, target.ID); err != nil {
+				t.Fatalf("SwipeRight: %v", err)
+			}
+			if len(swipes.charges) != 1 {
+				t.Fatalf("the swipe was charged %d times, want once", len(swipes.charges))
+			}
+			if day := swipes.charges[0].Day.Format(time.DateOnly); day != tt.wantDay {
+				t.Errorf("charged day = %s, want %s", day, tt.wantDay)
+			}
+
+			quota, err := service.GetQuota(user.ID)
+			if err != nil {
+				t.Fatalf("GetQuota: %v", err)
+			}
+			if quota.Used != 1 || quota.Remaining != 9 || !quota.ResetsAt.Equal(tt.wantResetsAt) || quota.TimeZone != tt.timeZone {
+				t.Errorf("quota = %d used, %d remaining, resets at %v in %s; want 1, 9, %v in %s",
+					quota.Used, quota.Remaining, quota.ResetsAt.UTC(), quota.TimeZone, tt.wantResetsAt, tt.timeZone)
+			}
+
+			service.now = func() time.Time { return checkedAt }
+			quota, err = service.GetQuota(user.ID)
+			if err != nil {
+				t.Fatalf("GetQuota: %v", err)
+			}
+			if quota.Used != tt.wantUsedLater || !quota.ResetsAt.Equal(tt.wantResetsAtLater) {
+				t.Errorf("an hour later quota = %d used, resets at %v; want %d, %v",
+					quota.Used, quota.ResetsAt.UTC(), tt.wantUsedLater, tt.wantResetsAtLater)
+			}
+		})
+	}
+}
+
+func TestUndoEndsMatchAndNotifiesBothUsers(t *testing.T) {
+	alice, bob, carol := &models.User{ID: uuid.New()}, &models.User{ID: uuid.New()}, &models.User{ID: uuid.New()}
+	users, swipes, publisher := newFakeUserRepo(alice, bob, carol), newFakeSwipeRepo(), &fakePublisher{}
+	service := newTestSwipeService(users, swipes, newFakeBlockRepo())
+	service.Config.UndoWindow, service.Config.RewindDailyLimit = time.Minute, 5
+	service.Events = publisher
+
+	rewind := &models.PremiumPackage{DurationMonths: 1, Entitlements: []models.PackageEntitlement{{Feature: models.FeatureRewind}}}
+	premium := NewPremiumService(newFakePremiumRepo(rewind), users, nil)
+	if _, err := premium.RegisterPremium(bob.ID, rewind.ID); err != nil {
+		t.Fatal(err)
+	}
+	service.Premium = premium
+
+	if _, err := service.SwipeRight(alice.ID, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	match, err := service.SwipeRight(bob.ID, alice.ID)
+	if err != nil || match == nil {
+		t.Fatalf("SwipeRight = %v, %v, want a match", match, err)
+	}
+
+	undone, err := service.UndoLastSwipe(bob.ID)
+	if err != nil {
+		t.Fatalf("UndoLastSwipe: %v", err)
+	}
+	if undone.TargetUserID != alice.ID {
+		t.Errorf("undid the swipe on %s, want %s", undone.TargetUserID, alice.ID)
+	}
+	stored := swipes.matches[0]
+	if stored.Status != models.MatchStatusUnmatched || stored.EndedByID == nil || *stored.EndedByID != bob.ID {
+		t.Errorf("match is %s, ended by %v; want unmatched by %s", stored.Status, stored.EndedByID, bob.ID)
+	}
+	if got := publisher.recipients(events.TypeMatchEnded); !equalUsers(got, []uuid.UUID{match.UserAID, match.UserBID}) {
+		t.Errorf("match.ended sent to %v, want both users", got)
+	}
+
+	// Undoing a swipe that made no match tells nobody
+	if err := service.SwipeLeft(bob.ID, carol.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.UndoLastSwipe(bob.ID); err != nil {
+		t.Fatalf("UndoLastSwipe: %v", err)
+	}
+	if got := publisher.recipients(events.TypeMatchEnded); len(got) != 2 {
+		t.Errorf("match.ended sent %d times, want 2", len(got))
+	}
+}
+
+func equalUsers(a, b []uuid.UUID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestQuotaFollowsPremiumEntitlements(t *testing.T) {
+	tests := []struct {
+		name           string
+		entitlements   []models.PackageEntitlement
+		wantLimit      int
+		wantUnlimited  bool
+		wantSuperLimit int
+		wantSuperFree  bool
+	}{
+		{"free user", nil, 10, false, 1, false},
+		{"unlimited swipes", []models.PackageEntitlement{{Feature: models.FeatureUnlimitedSwipes}}, 0, true, 1, false},
+		{"raised swipe limit", []models.PackageEntitlement{{Feature: models.FeatureUnlimitedSwipes, Limit: intPtr(25)}}, 25, false, 1, false},
+		{"limit below the free quota", []models.PackageEntitlement{{Feature: models.FeatureUnlimitedSwipes, Limit: intPtr(3)}}, 10, false, 1, false},
+		{"raised super likes", []models.PackageEntitlement{{Feature: models.FeatureSuperLikes, Limit: intPtr(5)}}, 10, false, 5, false},
+		{"unlimited super likes", []models.PackageEntitlement{{Feature: models.FeatureSuperLikes}}, 10, false, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{ID: uuid.New()}
+			users := newFakeUserRepo(user)
+			pkg := &models.PremiumPackage{DurationMonths: 1, Entitlements: tt.entitlements}
+			premium := NewPremiumService(newFakePremiumRepo(pkg), users, nil)
+			if tt.entitlements != nil {
+				if _, err := premium.RegisterPremium(user.ID, pkg.ID); err != nil {
+					t.Fatalf("RegisterPremium: %v", err)
+				}
+			}
+			swipes := newFakeSwipeRepo()
+			config := SwipeConfig{FreeDailyQuota: 10, FreeDailySuperLikes: 1}
+			service := NewSwipeService(users, swipes, newFakeBlockRepo(), premium, config, nil)
+
+			quota, err := service.GetQuota(user.ID)
+			if err != nil {
+				t.Fatalf("GetQuota: %v", err)
+			}
+			if quota.Limit != tt.wantLimit || quota.Unlimited != tt.wantUnlimited {
+				t.Errorf("swipe quota = limit %d, unlimited %t; want %d, %t", quota.Limit, quota.Unlimited, tt.wantLimit, tt.wantUnlimited)
+			}
+			if quota.SuperLikes.Limit != tt.wantSuperLimit || quota.SuperLikes.Unlimited != tt.wantSuperFree {
+				t.Errorf("super like quota = limit %d, unlimited %t; want %d, %t",
+					quota.SuperLikes.Limit, quota.SuperLikes.Unlimited, tt.wantSuperLimit, tt.wantSuperFree)
+			}
+
+			// The same allowance is enforced when swiping
+			swipeLimit := tt.wantLimit
+			if tt.wantUnlimited {
+				swipeLimit = 40
+			}
+			for i := 0; i < swipeLimit; i++ {
+				target := &models.User{ID: uuid.New()}
+				users.users[target.ID] = target
+				if _, err := service.SwipeRight(user.ID, target.ID); err != nil {
+					t.Fatalf("swipe %d: %v", i+1, err)
+				}
+			}
+			if !tt.wantUnlimited {
+				target := &models.User{ID: uuid.New()}
+				users.users[target.ID] = target
+				if _, err := service.SwipeRight(user.ID, target.ID); !errors.Is(err, ErrQuotaExceeded) {
+					t.Errorf("swipe over the limit: err = %v, want %v", err, ErrQuotaExceeded)
+				}
+			}
+			if limit := swipes.charges[0].Limit; (limit == nil) != tt.wantUnlimited {
+				t.Errorf("charged limit = %v, want unlimited %t", limit, tt.wantUnlimited)
+			}
+			if !tt.wantSuperFree {
+				for i := 0; i <= tt.wantSuperLimit; i++ {
+					target := &models.User{ID: uuid.New()}
+					users.users[target.ID] = target
+					_, err := service.SwipeSuper(user.ID, target.ID)
+					if i < tt.wantSuperLimit && err != nil {
+						t.Fatalf("super like %d: %v", i+1, err)
+					}
+					if i == tt.wantSuperLimit && !errors.Is(err, ErrNoSuperLikesLeft) {
+						t.Errorf("super like over the limit: err = %v, want %v", err, ErrNoSuperLikesLeft)
+					}
+				}
+			}
+		})
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
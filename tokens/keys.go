@@ -0,0 +1,213 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// MinHMACSecretLength is the shortest HS256 secret accepted, in bytes; a shorter one
+// can be brute forced from a single token
+const MinHMACSecretLength = 32
+
+// Key is a signing key identified by the "kid" header of the tokens it signs
+type Key struct {
+	ID        string
+	Algorithm string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACKey creates an HS256 key from a shared secret of at least MinHMACSecretLength bytes
+func NewHMACKey(kid, secret string) (*Key, error) {
+	if len(secret) < MinHMACSecretLength {
+		return nil, fmt.Errorf("key %q: HS256 secret must be at least %d bytes", kid, MinHMACSecretLength)
+	}
+	return &Key{
+		ID:        kid,
+		Algorithm: AlgHS256,
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}, nil
+}
+
+// GenerateHMACKey creates an HS256 key from a random secret, for environments that
+// do not need tokens to outlive the process
+func GenerateHMACKey(kid string) (*Key, error) {
+	secret := make([]byte, MinHMACSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("key %q: %w", kid, err)
+	}
+	return NewHMACKey(kid, string(secret))
+}
+
+// LoadKey builds a key for the algorithm. For HS256 value is the shared secret,
+// for RS256 and EdDSA it is the path to a PEM encoded private key.
+func LoadKey(kid, algorithm, value string) (*Key, error) {
+	if algorithm == AlgHS256 {
+		return NewHMACKey(kid, value)
+	}
+	if algorithm != AlgRS256 && algorithm != AlgEdDSA {
+		return nil, fmt.Errorf("key %q: unsupported algorithm %q", kid, algorithm)
+	}
+
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", kid, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %q: %s is not PEM encoded", kid, value)
+	}
+
+	var privateKey interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", kid, err)
+	}
+
+	switch k := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if algorithm != AlgRS256 {
+			break
+		}
+		return &Key{ID: kid, Algorithm: AlgRS256, method: jwt.SigningMethodRS256, signKey: k, verifyKey: &k.PublicKey}, nil
+	case ed25519.PrivateKey:
+		if algorithm != AlgEdDSA {
+			break
+		}
+		return &Key{ID: kid, Algorithm: AlgEdDSA, method: signingMethodEdDSA, signKey: k, verifyKey: k.Public()}, nil
+	}
+	return nil, fmt.Errorf("key %q: %s does not hold a %s private key", kid, value, algorithm)
+}
+
+// KeySet signs tokens with its active key and verifies tokens signed by any of its
+// keys, so a new key can be rolled out while tokens signed by the old one stay valid.
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+	order  []string
+}
+
+// NewKeySet creates a key set that signs with the key identified by activeKID
+func NewKeySet(activeKID string, keys ...*Key) (*KeySet, error) {
+	ks := &KeySet{keys: map[string]*Key{}}
+	for _, key := range keys {
+		if _, ok := ks.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		ks.keys[key.ID] = key
+		ks.order = append(ks.order, key.ID)
+	}
+
+	active, ok := ks.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q is not configured", activeKID)
+	}
+	ks.active = active
+	return ks, nil
+}
+
+// Sign issues a token for the claims using the active key
+func (ks *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.method, claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.signKey)
+}
+
+// Parse verifies the token's signature and expiry and returns its claims
+func (ks *KeySet) Parse(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ks.keys[kid]
+		if !ok {
+			return nil, jwt.NewValidationError("unknown signing key", jwt.ValidationErrorUnverifiable)
+		}
+		// Ensure the token uses the algorithm of its key, never one chosen by the caller
+		if token.Method.Alg() != key.Algorithm {
+			return nil, jwt.NewValidationError("invalid signing method", jwt.ValidationErrorSignatureInvalid)
+		}
+		return key.verifyKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public halves of the asymmetric keys. HMAC secrets are never published.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, kid := range ks.order {
+		key := ks.keys[kid]
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.Algorithm,
+				N:         base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.Algorithm,
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return jwks
+}
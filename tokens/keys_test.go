@@ -0,0 +1,64 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestNewHMACKeySecretLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{"empty", "", true},
+		{"short", "just_for_test", true},
+		{"one byte short", strings.Repeat("s", MinHMACSecretLength-1), true},
+		{"minimum", strings.Repeat("s", MinHMACSecretLength), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewHMACKey("test", tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewHMACKey error = %v, want error %t", err, tt.wantErr)
+			}
+			if _, err := LoadKey("test", AlgHS256, tt.secret); (err != nil) != tt.wantErr {
+				t.Errorf("LoadKey error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateHMACKeySignsVerifiableTokens(t *testing.T) {
+	key, err := GenerateHMACKey("dev")
+	if err != nil {
+		t.Fatalf("GenerateHMACKey: %v", err)
+	}
+	other, err := GenerateHMACKey("dev")
+	if err != nil {
+		t.Fatalf("GenerateHMACKey: %v", err)
+	}
+	keys, err := NewKeySet(key.ID, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKeys, err := NewKeySet(other.ID, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := keys.Sign(jwt.MapClaims{"user_id": "u", "exp": time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if _, err := keys.Parse(token); err != nil {
+		t.Errorf("Parse with the signing key: %v", err)
+	}
+	if _, err := otherKeys.Parse(token); err == nil {
+		t.Error("a token verified against another generated key")
+	}
+}